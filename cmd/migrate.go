@@ -3,56 +3,285 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
-	"evaframe/internal/models"
+	"evaframe/internal/migrations"
+	"evaframe/internal/seeders"
 	"evaframe/pkg/config"
 	"evaframe/pkg/database"
 	"evaframe/pkg/logger"
+	"evaframe/pkg/migrate"
+	"evaframe/pkg/registry"
 
 	"github.com/spf13/cobra"
+	"gorm.io/gorm"
 )
 
 func init() {
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateRedoCmd, migrateToCmd, migrateCreateCmd, migrateDiffCmd)
 	rootCmd.AddCommand(migrateCmd)
 }
 
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run database migrations",
-	Long:  `Run GORM auto-migration to create/update database schema.`,
+	Long: `Apply pending versioned migrations and seed built-in data.
+
+Migrations are registered in internal/migrations or written as SQL files in
+database.migrations_dir. Applied versions are recorded in schema_migrations.`,
+	Args: cobra.NoArgs,
+	Run:  runMigrateUp,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations and seed built-in data",
+	Args:  cobra.NoArgs,
+	Run:   runMigrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [n]",
+	Short: "Roll back the last n applied migrations (default 1)",
+	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		// 加载配置
-		cfg, err := config.NewConfig(configFile)
+		steps := 1
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				fmt.Printf("Invalid number of migrations: %s\n", args[0])
+				os.Exit(1)
+			}
+			steps = n
+		}
+
+		m, _, _, cleanup := openMigrator()
+		defer cleanup()
+		n, err := m.Down(steps)
 		if err != nil {
-			fmt.Printf("Failed to load config: %v\n", err)
+			fmt.Printf("Rollback failed: %v\n", err)
+			cleanup()
 			os.Exit(1)
 		}
+		fmt.Printf("Rolled back %d migration(s)\n", n)
+	},
+}
 
-		// 初始化日志记录器
-		appLogger, err := logger.NewLogger(cfg)
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending migrations",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, _, _, cleanup := openMigrator()
+		defer cleanup()
+		statuses, err := m.Status()
 		if err != nil {
-			fmt.Printf("Failed to initialize logger: %v\n", err)
+			fmt.Printf("Failed to read migration status: %v\n", err)
+			cleanup()
+			os.Exit(1)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", ""
+			if s.Applied {
+				state, appliedAt = "applied", s.AppliedAt.Local().Format(time.DateTime)
+			}
+			if s.Missing {
+				state = "applied (missing)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		w.Flush()
+	},
+}
+
+var migrateRedoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Roll back and re-apply the last applied migration",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, _, _, cleanup := openMigrator()
+		defer cleanup()
+		if err := m.Redo(); err != nil {
+			fmt.Printf("Redo failed: %v\n", err)
+			cleanup()
 			os.Exit(1)
 		}
+	},
+}
 
-		// 连接数据库
-		db, err := database.NewDB(cfg, appLogger)
+var migrateToCmd = &cobra.Command{
+	Use:   "to <version>",
+	Short: "Migrate up or down to the given version (0 rolls back everything)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		m, _, _, cleanup := openMigrator()
+		defer cleanup()
+		n, err := m.To(args[0])
 		if err != nil {
-			fmt.Printf("Failed to connect database: %v\n", err)
+			fmt.Printf("Migration failed: %v\n", err)
+			cleanup()
 			os.Exit(1)
 		}
+		fmt.Printf("Database is at version %s (%d migration(s) changed)\n", args[0], n)
+	},
+}
 
-		fmt.Println("Starting database migration...")
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create empty up/down SQL migration files in database.migrations_dir",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.NewConfig(configOptions())
+		if err != nil {
+			fmt.Printf("Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
 
-		// 自动迁移表结构
-		err = db.AutoMigrate(
-			&models.User{},
-		)
+		paths, err := migrate.Create(cfg.Database.MigrationsDir, args[0], time.Now())
 		if err != nil {
-			fmt.Printf("Migration failed: %v\n", err)
+			fmt.Printf("Failed to create migration: %v\n", err)
+			os.Exit(1)
+		}
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+	},
+}
+
+var migrateDiffCmd = &cobra.Command{
+	Use:   "diff <name>",
+	Short: "Generate a SQL migration from the difference between models and the database",
+	Long: `Compare the registered models with the current database schema and write up/down SQL
+files for the current database type into database.migrations_dir.
+
+Destructive changes (dropped tables or columns, changed column types) are marked
+with DESTRUCTIVE in the generated files and must be reviewed before applying.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		m, db, cfg, cleanup := openMigrator()
+		defer cleanup()
+
+		// 有未执行的迁移时生成的差异会与它们重复
+		statuses, err := m.Status()
+		if err != nil {
+			fmt.Printf("Failed to read migration status: %v\n", err)
+			cleanup()
+			os.Exit(1)
+		}
+		for _, s := range statuses {
+			if !s.Applied {
+				fmt.Printf("Migration %s_%s is pending, run migrate up first\n", s.Version, s.Name)
+				cleanup()
+				os.Exit(1)
+			}
+		}
+
+		changes, err := migrate.Diff(db, registry.Values())
+		if err != nil {
+			fmt.Printf("Failed to compare schema: %v\n", err)
+			cleanup()
 			os.Exit(1)
 		}
+		if len(changes) == 0 {
+			fmt.Println("Database schema matches the models, nothing to generate")
+			return
+		}
+
+		destructive := false
+		for _, c := range changes {
+			switch {
+			case c.Manual:
+				fmt.Println("  MANUAL       ", c.Description)
+				destructive = true
+			case c.Destructive:
+				fmt.Println("  DESTRUCTIVE  ", c.Description)
+				destructive = true
+			default:
+				fmt.Println("               ", c.Description)
+			}
+		}
 
-		fmt.Println("Database migration completed successfully!")
+		paths, err := migrate.WriteDiff(cfg.Database.MigrationsDir, args[0], db.Dialector.Name(), changes, time.Now())
+		if err != nil {
+			fmt.Printf("Failed to write migration: %v\n", err)
+			cleanup()
+			os.Exit(1)
+		}
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		if destructive {
+			fmt.Println("Review the DESTRUCTIVE and MANUAL changes before applying the migration")
+		}
 	},
 }
+
+// runMigrateUp 执行全部未执行的迁移后写入内置数据
+func runMigrateUp(cmd *cobra.Command, args []string) {
+	m, db, _, cleanup := openMigrator()
+	defer cleanup()
+
+	fmt.Println("Starting database migration...")
+	n, err := m.Up()
+	if err != nil {
+		fmt.Printf("Migration failed: %v\n", err)
+		cleanup()
+		os.Exit(1)
+	}
+	if n == 0 {
+		fmt.Println("No pending migrations")
+	}
+
+	// 写入内置数据
+	if err := seeders.Run(db); err != nil {
+		fmt.Printf("Seeding failed: %v\n", err)
+		cleanup()
+		os.Exit(1)
+	}
+
+	fmt.Println("Database migration completed successfully!")
+}
+
+// openMigrator 连接数据库并加载代码中注册的迁移和迁移目录中的 SQL 迁移
+func openMigrator() (*migrate.Migrator, *gorm.DB, *config.Config, func()) {
+	// 加载配置
+	cfg, err := config.NewConfig(configOptions())
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 初始化日志记录器
+	appLogger, err := logger.NewLogger(cfg)
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 连接数据库
+	db, closeDB, err := database.NewDB(cfg, appLogger)
+	if err != nil {
+		fmt.Printf("Failed to connect database: %v\n", err)
+		os.Exit(1)
+	}
+
+	files, err := migrate.LoadDir(cfg.Database.MigrationsDir, db.Dialector.Name())
+	if err != nil {
+		fmt.Printf("Failed to load migrations: %v\n", err)
+		closeDB()
+		os.Exit(1)
+	}
+	m, err := migrate.New(db, append(migrations.All(), files...))
+	if err != nil {
+		fmt.Printf("Failed to load migrations: %v\n", err)
+		closeDB()
+		os.Exit(1)
+	}
+	m.Logf = func(format string, args ...any) { fmt.Printf(format, args...) }
+	return m, db, cfg, closeDB
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"evaframe/internal/dao/gorm"
+	"evaframe/internal/models"
+	"evaframe/pkg/config"
+	"evaframe/pkg/database"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/session"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	roleCmd.AddCommand(roleAssignCmd)
+	rootCmd.AddCommand(roleCmd)
+}
+
+var roleCmd = &cobra.Command{
+	Use:   "role",
+	Short: "Manage user roles",
+}
+
+var roleAssignCmd = &cobra.Command{
+	Use:   "assign <email> <role>...",
+	Short: "Assign roles to a user",
+	Long:  `Replace the roles of the user with the given email, e.g. to bootstrap the first admin account.`,
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		// 加载配置
+		cfg, err := config.NewConfig(configOptions())
+		if err != nil {
+			fmt.Printf("Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 初始化日志记录器
+		appLogger, err := logger.NewLogger(cfg)
+		if err != nil {
+			fmt.Printf("Failed to initialize logger: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 连接数据库
+		db, closeDB, err := database.NewDB(cfg, appLogger)
+		if err != nil {
+			fmt.Printf("Failed to connect database: %v\n", err)
+			os.Exit(1)
+		}
+		defer closeDB()
+
+		var user models.User
+		if err := db.Where("email = ?", args[0]).First(&user).Error; err != nil {
+			fmt.Printf("User not found: %v\n", err)
+			os.Exit(1)
+		}
+
+		var roles []models.Role
+		if err := db.Where("name IN ?", args[1:]).Find(&roles).Error; err != nil || len(roles) != len(args[1:]) {
+			fmt.Println("Role not found, run migrate first to seed built-in roles")
+			os.Exit(1)
+		}
+
+		if err := db.Model(&user).Association("Roles").Replace(roles); err != nil {
+			fmt.Printf("Failed to assign roles: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 与 API 分配角色一致，吊销刷新令牌并删除会话，让用户按新角色重新登录。
+		// memory 会话存储只存在于服务进程中，需要重启服务才会清除
+		if err := gorm.NewRefreshTokenDAO(db).RevokeByUser(user.ID, time.Now()); err != nil {
+			fmt.Printf("Failed to revoke refresh tokens: %v\n", err)
+			os.Exit(1)
+		}
+		store, err := session.NewStore(cfg, db)
+		if err == nil {
+			err = store.DeleteByUser(user.ID, "")
+		}
+		if err != nil {
+			fmt.Printf("Failed to delete sessions: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Roles of %s set to %v\n", user.Email, args[1:])
+	},
+}
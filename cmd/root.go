@@ -5,6 +5,7 @@ import (
 	"evaframe/pkg/logger"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,25 +21,67 @@ var (
 var rootCmd = &cobra.Command{
 	Use:   "evaframe",
 	Short: "A modern Go web framework",
-	Long:  `EvaFrame is a modern Go web framework built with Gin, GORM, and dependency injection.`,
+	Long: `EvaFrame is a modern Go web framework built with Gin, GORM, and dependency injection.
+
+Every config key can be overridden by an environment variable (server.port -> EVAFRAME_SERVER_PORT)
+or by --set key=value. Precedence from low to high:
+config file < profile file < config sources (--source) < environment < --set.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	// 在解析完命令行参数后初始化全局单例日志，--config 和 --set 才能生效
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := openConfigSources(); err != nil {
+			return err
+		}
+		cfg, err := config.NewConfig(configOptions())
+		if err != nil {
+			return err
+		}
+		return logger.Init(cfg)
+	},
 }
 
-var configFile string
+var (
+	configFile       string
+	configProfile    string
+	configOverrides  []string
+	configSourceURLs []string
+	configSources    []config.Source
+)
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "config/config.yaml", "config file path")
+	rootCmd.PersistentFlags().StringVar(&configProfile, "profile", "", "config profile, merges config.<profile>.yaml over the config file (default $"+config.ProfileEnv+")")
+	rootCmd.PersistentFlags().StringArrayVar(&configOverrides, "set", nil, "override a config key, e.g. --set server.port=9090 (repeatable)")
+	rootCmd.PersistentFlags().StringArrayVar(&configSourceURLs, "source", nil, "config source such as dir:config/remote, watched for changes (repeatable, default $"+config.SourcesEnv+")")
 }
 
-func Execute() {
-	// 初始化全局单例日志
-	cfg, err := config.NewConfig(configFile)
-	if err != nil {
-		panic(err)
+// openConfigSources 创建 --source 指定的配置源，未指定时读取逗号分隔的 EVAFRAME_CONFIG_SOURCES
+func openConfigSources() error {
+	urls := configSourceURLs
+	if len(urls) == 0 {
+		if env := os.Getenv(config.SourcesEnv); env != "" {
+			urls = strings.Split(env, ",")
+		}
 	}
-	if err := logger.Init(cfg); err != nil {
-		panic(err)
+
+	configSources = configSources[:0]
+	for _, u := range urls {
+		src, err := config.OpenSource(strings.TrimSpace(u))
+		if err != nil {
+			return err
+		}
+		configSources = append(configSources, src)
 	}
+	return nil
+}
+
+// configOptions 根据命令行参数返回配置加载选项
+func configOptions() config.Options {
+	return config.Options{Path: configFile, Profile: configProfile, Overrides: configOverrides, Sources: configSources}
+}
 
+func Execute() {
 	// 如果没有提供子命令，设置为 serve
 	if len(os.Args) == 1 {
 		args := append([]string{os.Args[0]}, "serve")
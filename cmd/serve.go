@@ -24,7 +24,7 @@ var serveCmd = &cobra.Command{
 	Long:  `Start the web server with the specified configuration.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 初始化应用
-		application, cleanup, err := app.InitializeApp(configFile)
+		application, cleanup, err := app.InitializeApp(configOptions())
 		if err != nil {
 			fmt.Printf("Failed to initialize app: %v\n", err)
 			os.Exit(1)
@@ -1,43 +1,99 @@
 package app
 
 import (
+	"fmt"
+
 	"evaframe/internal/handler"
 	"evaframe/pkg/config"
+	"evaframe/pkg/jwt"
 	"evaframe/pkg/logger"
+	"evaframe/pkg/loginguard"
 	"evaframe/pkg/middleware"
+	"evaframe/pkg/oidc"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type Application struct {
-	Config *config.Config
-	Router *gin.Engine
-	User   *handler.UserHandler
-	Logger *logger.Logger
+	Config  *config.Config
+	Router  *gin.Engine
+	User    *handler.UserHandler
+	JWKS    *handler.JWKSHandler
+	MFA     *handler.MFAHandler
+	Account *handler.AccountHandler
+	APIKey  *handler.APIKeyHandler
+	OIDC    *handler.OIDCHandler
+	OAuth   *handler.OAuthHandler
+	Logger  *logger.Logger
 }
 
 func NewApplication(
+	holder *config.Holder,
 	cfg *config.Config,
 	user *handler.UserHandler,
+	jwks *handler.JWKSHandler,
+	mfa *handler.MFAHandler,
+	account *handler.AccountHandler,
+	apiKey *handler.APIKeyHandler,
+	oidcHandler *handler.OIDCHandler,
+	oauth *handler.OAuthHandler,
+	mockIssuer *oidc.MockIssuer,
 	mws *middleware.Middlewares,
 	logger *logger.Logger,
-) *Application {
+	db *gorm.DB,
+	j *jwt.JWT,
+	guard *loginguard.Guard,
+) (*Application, error) {
+	// 配置热更新
+	watchConfig(holder, logger, db, j, guard)
+
 	// 设置Gin模式
 	gin.SetMode(cfg.Server.Mode)
 
 	// 创建路由器
 	router := gin.New()
+	// 登录防护按客户端 IP 计数，只信任配置的代理转发的来源地址
+	// 配置错误时代理设置不生效，伪造的 X-Forwarded-For 可以绕过按 IP 的锁定，因此直接拒绝启动
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid server.trusted_proxies: %w", err)
+	}
 	router.Use(gin.HandlerFunc(mws.Logger))
 	router.Use(gin.HandlerFunc(mws.Recovery))
+	router.Use(gin.HandlerFunc(mws.CORS))
+	router.Use(gin.HandlerFunc(mws.RateLimit))
 
 	// 注册路由
+	jwks.RegisterRoutes(router)
 	apiV1 := router.Group("/api/v1")
 	user.RegisterRoutes(apiV1, gin.HandlerFunc(mws.Auth))
+	mfa.RegisterRoutes(apiV1, gin.HandlerFunc(mws.Auth))
+	account.RegisterRoutes(apiV1, gin.HandlerFunc(mws.Auth))
+	apiKey.RegisterRoutes(apiV1, gin.HandlerFunc(mws.Auth))
+	oidcHandler.RegisterRoutes(apiV1)
+	oauth.RegisterRoutes(router, apiV1, gin.HandlerFunc(mws.Auth))
 
-	return &Application{
-		Config: cfg,
-		Router: router,
-		User:   user,
-		Logger: logger,
+	// 内置模拟 OIDC 提供方，仅在配置启用时注册
+	if mockIssuer != nil {
+		logger.Warn("oidc",
+			zap.String("event", "MOCK OIDC PROVIDER ENABLED"),
+			zap.String("path", oidc.MockPath),
+			zap.String("detail", "anyone can sign in as any user without a password, never enable oidc.mock in production"),
+		)
+		mockIssuer.RegisterRoutes(router)
 	}
+
+	return &Application{
+		Config:  cfg,
+		Router:  router,
+		User:    user,
+		JWKS:    jwks,
+		MFA:     mfa,
+		Account: account,
+		APIKey:  apiKey,
+		OIDC:    oidcHandler,
+		OAuth:   oauth,
+		Logger:  logger,
+	}, nil
 }
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"time"
+
+	"evaframe/pkg/config"
+	"evaframe/pkg/database"
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/loginguard"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// watchConfig 让运行中的组件跟随配置热更新：日志级别、慢查询阈值、连接池、JWT 设置和登录锁定阈值
+//
+// CORS 和限流中间件在创建时自行订阅；其余配置（端口、数据库连接等）需要重启才能生效。
+func watchConfig(holder *config.Holder, log *logger.Logger, db *gorm.DB, j *jwt.JWT, guard *loginguard.Guard) {
+	holder.OnError(func(err error) {
+		log.Error("config reload rejected, keeping the current config", zap.Error(err))
+	})
+
+	// 无法应用的配置整体拒绝，不会出现部分组件已更新的情况
+	holder.AddValidator(func(c *config.Config) error {
+		_, err := logger.ParseLevel(c.Logger.Level)
+		return err
+	})
+	holder.AddValidator(jwt.Validate)
+
+	config.OnChange(holder, func(c *config.Config) string { return c.Logger.Level }, func(level string) {
+		log.LogIf(log.SetLevel(level))
+	})
+	config.OnChange(holder, func(c *config.Config) time.Duration { return c.Database.SlowThreshold }, func(threshold time.Duration) {
+		database.SetSlowThreshold(db, threshold)
+	})
+	config.OnChange(holder, database.PoolFromConfig, func(pool database.Pool) {
+		database.ConfigurePool(db, pool)
+	})
+	holder.Subscribe(func(old, next *config.Config) {
+		// 已通过 jwt.Validate 校验，这里不会失败
+		log.LogIf(j.Reload(next))
+		guard.Reload(next)
+		log.Info("config reloaded")
+	})
+}
@@ -9,29 +9,36 @@ import (
 	"evaframe/internal/service"
 	"evaframe/pkg/config"
 	"evaframe/pkg/database"
+	"evaframe/pkg/hasher"
 	"evaframe/pkg/jwt"
 	"evaframe/pkg/logger"
+	"evaframe/pkg/loginguard"
+	"evaframe/pkg/mailer"
 	"evaframe/pkg/middleware"
+	"evaframe/pkg/oidc"
+	"evaframe/pkg/revocation"
+	"evaframe/pkg/session"
 	"evaframe/pkg/validator"
 
 	"github.com/google/wire"
 )
 
-// NewConfigWithPath 创建一个包装函数来接收configPath参数
-func NewConfigWithPath(configPath string) (*config.Config, error) {
-	return config.NewConfig(configPath)
-}
-
 // InitializeApp 使用Wire进行依赖注入
-func InitializeApp(configPath string) (*Application, func(), error) {
+func InitializeApp(opts config.Options) (*Application, func(), error) {
 	panic(wire.Build(
 		// 配置
-		NewConfigWithPath,
+		config.ProviderSet,
 
 		// 基础设施
 		logger.ProviderSet,
 		database.ProviderSet,
 		jwt.ProviderSet,
+		hasher.ProviderSet,
+		revocation.ProviderSet,
+		session.ProviderSet,
+		loginguard.ProviderSet,
+		mailer.ProviderSet,
+		oidc.ProviderSet,
 		validator.ProviderSet,
 		middleware.ProviderSet,
 
@@ -12,45 +12,115 @@ import (
 	"evaframe/internal/service"
 	"evaframe/pkg/config"
 	"evaframe/pkg/database"
+	"evaframe/pkg/hasher"
 	"evaframe/pkg/jwt"
 	"evaframe/pkg/logger"
+	"evaframe/pkg/loginguard"
+	"evaframe/pkg/mailer"
 	"evaframe/pkg/middleware"
+	"evaframe/pkg/oidc"
+	"evaframe/pkg/revocation"
+	"evaframe/pkg/session"
 	"evaframe/pkg/validator"
 )
 
 // Injectors from wire.go:
 
 // InitializeApp 使用Wire进行依赖注入
-func InitializeApp(configPath string) (*Application, func(), error) {
-	config, err := NewConfigWithPath(configPath)
+func InitializeApp(opts config.Options) (*Application, func(), error) {
+	holder, err := config.Watch(opts)
 	if err != nil {
 		return nil, nil, err
 	}
-	loggerLogger, err := logger.NewLogger(config)
+	configConfig := config.Current(holder)
+	loggerLogger, err := logger.NewLogger(configConfig)
 	if err != nil {
 		return nil, nil, err
 	}
-	jwtJWT := jwt.NewJWT(config)
-	db, err := database.NewDB(config, loggerLogger)
+	jwtJWT, err := jwt.NewJWT(configConfig)
 	if err != nil {
 		return nil, nil, err
 	}
+	passwordHasher, err := hasher.NewPasswordHasher(configConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup, err := database.NewDB(configConfig, loggerLogger)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := session.NewStore(configConfig, db)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	manager, err := session.NewManager(configConfig, store)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 	userDAO := gorm.NewUserDAO(db)
-	userService := service.NewUserService(config, loggerLogger, jwtJWT, userDAO)
+	refreshTokenDAO := gorm.NewRefreshTokenDAO(db)
+	tokenService := service.NewTokenService(configConfig, loggerLogger, jwtJWT, manager, userDAO, refreshTokenDAO)
+	guard := loginguard.NewGuard(configConfig)
+	revocationStore, err := revocation.NewStore(configConfig, db)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	recoveryCodeDAO := gorm.NewRecoveryCodeDAO(db)
+	mfaService := service.NewMFAService(configConfig, loggerLogger, jwtJWT, tokenService, guard, revocationStore, userDAO, recoveryCodeDAO)
+	mailerMailer, err := mailer.NewMailer(configConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	actionTokenDAO := gorm.NewActionTokenDAO(db)
+	accountService := service.NewAccountService(configConfig, loggerLogger, jwtJWT, passwordHasher, mailerMailer, userDAO, tokenService, actionTokenDAO)
+	roleDAO := gorm.NewRoleDAO(db)
+	userService := service.NewUserService(configConfig, loggerLogger, jwtJWT, passwordHasher, tokenService, mfaService, accountService, guard, revocationStore, userDAO, roleDAO)
 	validatorValidator := validator.NewValidator()
-	userHandler := handler.NewUserHandler(userService, validatorValidator, loggerLogger)
+	userHandler := handler.NewUserHandler(userService, manager, validatorValidator, loggerLogger)
+	jwksHandler := handler.NewJWKSHandler(jwtJWT)
+	mfaHandler := handler.NewMFAHandler(mfaService, manager, validatorValidator, loggerLogger)
+	accountHandler := handler.NewAccountHandler(accountService, validatorValidator, loggerLogger)
+	apiKeyDAO := gorm.NewAPIKeyDAO(db)
+	apiKeyService := service.NewAPIKeyService(loggerLogger, userDAO, apiKeyDAO)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, validatorValidator, loggerLogger)
+	mockIssuer, err := oidc.NewMockIssuer(configConfig)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	registry, err := oidc.NewRegistry(configConfig, mockIssuer)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	stateStore := oidc.NewStateStore()
+	userIdentityDAO := gorm.NewUserIdentityDAO(db)
+	oidcService := service.NewOIDCService(loggerLogger, passwordHasher, registry, stateStore, userService, userDAO, userIdentityDAO)
+	oidcHandler := handler.NewOIDCHandler(oidcService, manager, loggerLogger)
+	oAuthClientDAO := gorm.NewOAuthClientDAO(db)
+	oAuthCodeDAO := gorm.NewOAuthCodeDAO(db)
+	oAuthService := service.NewOAuthService(loggerLogger, jwtJWT, revocationStore, userDAO, oAuthClientDAO, oAuthCodeDAO)
+	oAuthHandler := handler.NewOAuthHandler(oAuthService, configConfig, validatorValidator, loggerLogger)
 	loggerMiddleware := middleware.NewLoggerMiddleware(loggerLogger)
 	recoveryMiddleware := middleware.NewRecoveryMiddleware(loggerLogger)
-	authMiddleware := middleware.NewAuthMiddleware(jwtJWT)
-	middlewares := middleware.NewMiddlewares(loggerMiddleware, recoveryMiddleware, authMiddleware)
-	application := NewApplication(config, userHandler, middlewares, loggerLogger)
+	authMiddleware, err := middleware.NewAuthMiddleware(configConfig, jwtJWT, revocationStore, apiKeyService, manager)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	corsMiddleware := middleware.NewCORSMiddleware(holder)
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(holder)
+	middlewares := middleware.NewMiddlewares(loggerMiddleware, recoveryMiddleware, authMiddleware, corsMiddleware, rateLimitMiddleware)
+	application, err := NewApplication(holder, configConfig, userHandler, jwksHandler, mfaHandler, accountHandler, apiKeyHandler, oidcHandler, oAuthHandler, mockIssuer, middlewares, loggerLogger, db, jwtJWT, guard)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 	return application, func() {
+		cleanup()
 	}, nil
 }
-
-// wire.go:
-
-// NewConfigWithPath 创建一个包装函数来接收configPath参数
-func NewConfigWithPath(configPath string) (*config.Config, error) {
-	return config.NewConfig(configPath)
-}
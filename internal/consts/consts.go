@@ -1 +1,16 @@
 package consts
+
+// 角色
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// 权限，命名格式为 "资源:操作"
+const (
+	PermUsersList    = "users:list"
+	PermUsersDelete  = "users:delete"
+	PermUsersRestore = "users:restore"
+	PermRolesAssign  = "roles:assign"
+	PermOAuthClients = "oauth:clients"
+)
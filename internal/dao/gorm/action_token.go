@@ -0,0 +1,40 @@
+package gorm
+
+import (
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// ActionTokenDAOImpl 实现 service.ActionTokenDAO 接口
+type ActionTokenDAOImpl struct {
+	db *gorm.DB
+}
+
+// NewActionTokenDAO 返回接口类型
+func NewActionTokenDAO(db *gorm.DB) service.ActionTokenDAO {
+	return &ActionTokenDAOImpl{db: db}
+}
+
+func (d *ActionTokenDAOImpl) Create(token *models.ActionToken) error {
+	return d.db.Create(token).Error
+}
+
+func (d *ActionTokenDAOImpl) Consume(tokenID, purpose string, usedAt time.Time) (bool, error) {
+	result := d.db.Model(&models.ActionToken{}).
+		Where("token_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenID, purpose, usedAt).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (d *ActionTokenDAOImpl) InvalidateAll(userID uint, purpose string, usedAt time.Time) error {
+	return d.db.Model(&models.ActionToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", usedAt).Error
+}
@@ -0,0 +1,53 @@
+package gorm
+
+import (
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyDAOImpl 实现 service.APIKeyDAO 接口
+type APIKeyDAOImpl struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyDAO 返回接口类型
+func NewAPIKeyDAO(db *gorm.DB) service.APIKeyDAO {
+	return &APIKeyDAOImpl{db: db}
+}
+
+func (d *APIKeyDAOImpl) Create(key *models.APIKey) error {
+	return d.db.Create(key).Error
+}
+
+func (d *APIKeyDAOImpl) GetByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := d.db.Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (d *APIKeyDAOImpl) ListByUser(userID uint) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	err := d.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (d *APIKeyDAOImpl) Revoke(id, userID uint, revokedAt time.Time) (bool, error) {
+	result := d.db.Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (d *APIKeyDAOImpl) TouchLastUsed(id uint, usedAt time.Time) error {
+	return d.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
@@ -2,4 +2,14 @@ package gorm
 
 import "github.com/google/wire"
 
-var ProviderSet = wire.NewSet(NewUserDAO)
+var ProviderSet = wire.NewSet(
+	NewUserDAO,
+	NewRefreshTokenDAO,
+	NewRoleDAO,
+	NewRecoveryCodeDAO,
+	NewActionTokenDAO,
+	NewAPIKeyDAO,
+	NewUserIdentityDAO,
+	NewOAuthClientDAO,
+	NewOAuthCodeDAO,
+)
@@ -0,0 +1,87 @@
+package gorm
+
+import (
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// OAuthClientDAOImpl 实现 service.OAuthClientDAO 接口
+type OAuthClientDAOImpl struct {
+	db *gorm.DB
+}
+
+// NewOAuthClientDAO 返回接口类型
+func NewOAuthClientDAO(db *gorm.DB) service.OAuthClientDAO {
+	return &OAuthClientDAOImpl{db: db}
+}
+
+func (d *OAuthClientDAOImpl) Create(client *models.OAuthClient) error {
+	return d.db.Create(client).Error
+}
+
+func (d *OAuthClientDAOImpl) GetByClientID(clientID string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	err := d.db.Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (d *OAuthClientDAOImpl) List() ([]*models.OAuthClient, error) {
+	var clients []*models.OAuthClient
+	err := d.db.Order("id").Find(&clients).Error
+	return clients, err
+}
+
+func (d *OAuthClientDAOImpl) Delete(id uint) (bool, error) {
+	result := d.db.Delete(&models.OAuthClient{}, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// OAuthCodeDAOImpl 实现 service.OAuthCodeDAO 接口
+type OAuthCodeDAOImpl struct {
+	db *gorm.DB
+}
+
+// NewOAuthCodeDAO 返回接口类型
+func NewOAuthCodeDAO(db *gorm.DB) service.OAuthCodeDAO {
+	return &OAuthCodeDAOImpl{db: db}
+}
+
+func (d *OAuthCodeDAOImpl) Create(code *models.OAuthAuthorizationCode) error {
+	return d.db.Create(code).Error
+}
+
+func (d *OAuthCodeDAOImpl) GetByHash(hash string) (*models.OAuthAuthorizationCode, error) {
+	var code models.OAuthAuthorizationCode
+	err := d.db.Where("code_hash = ?", hash).First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (d *OAuthCodeDAOImpl) MarkUsed(id uint, usedAt time.Time) (bool, error) {
+	result := d.db.Model(&models.OAuthAuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (d *OAuthCodeDAOImpl) SetToken(id uint, tokenID string, expiresAt time.Time) error {
+	return d.db.Model(&models.OAuthAuthorizationCode{}).Where("id = ?", id).Updates(map[string]any{
+		"token_id":         tokenID,
+		"token_expires_at": expiresAt,
+	}).Error
+}
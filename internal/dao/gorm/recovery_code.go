@@ -0,0 +1,42 @@
+package gorm
+
+import (
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeDAOImpl 实现 service.RecoveryCodeDAO 接口
+type RecoveryCodeDAOImpl struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeDAO 返回接口类型
+func NewRecoveryCodeDAO(db *gorm.DB) service.RecoveryCodeDAO {
+	return &RecoveryCodeDAOImpl{db: db}
+}
+
+func (d *RecoveryCodeDAOImpl) Replace(userID uint, codes []models.RecoveryCode) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (d *RecoveryCodeDAOImpl) Use(userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	result := d.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
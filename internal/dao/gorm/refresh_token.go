@@ -0,0 +1,55 @@
+package gorm
+
+import (
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenDAOImpl 实现 service.RefreshTokenDAO 接口
+type RefreshTokenDAOImpl struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenDAO 返回接口类型
+func NewRefreshTokenDAO(db *gorm.DB) service.RefreshTokenDAO {
+	return &RefreshTokenDAOImpl{db: db}
+}
+
+func (d *RefreshTokenDAOImpl) Create(token *models.RefreshToken) error {
+	return d.db.Create(token).Error
+}
+
+func (d *RefreshTokenDAOImpl) GetByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := d.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (d *RefreshTokenDAOImpl) MarkUsed(id uint, usedAt time.Time) (bool, error) {
+	result := d.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (d *RefreshTokenDAOImpl) RevokeFamily(familyID string, revokedAt time.Time) error {
+	return d.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (d *RefreshTokenDAOImpl) RevokeByUser(userID uint, revokedAt time.Time) error {
+	return d.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
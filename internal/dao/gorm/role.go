@@ -0,0 +1,24 @@
+package gorm
+
+import (
+	"evaframe/internal/models"
+	"evaframe/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// RoleDAOImpl 实现 service.RoleDAO 接口
+type RoleDAOImpl struct {
+	db *gorm.DB
+}
+
+// NewRoleDAO 返回接口类型
+func NewRoleDAO(db *gorm.DB) service.RoleDAO {
+	return &RoleDAOImpl{db: db}
+}
+
+func (d *RoleDAOImpl) GetByNames(names []string) ([]models.Role, error) {
+	var roles []models.Role
+	err := d.db.Where("name IN ?", names).Find(&roles).Error
+	return roles, err
+}
@@ -1,6 +1,8 @@
 package gorm
 
 import (
+	"time"
+
 	"evaframe/internal/models"
 	"evaframe/internal/service"
 
@@ -23,7 +25,7 @@ func (d *UserDAOImpl) Create(user *models.User) error {
 
 func (d *UserDAOImpl) GetByID(id uint) (*models.User, error) {
 	var user models.User
-	err := d.db.First(&user, id).Error
+	err := d.db.Preload("Roles.Permissions").First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -32,15 +34,77 @@ func (d *UserDAOImpl) GetByID(id uint) (*models.User, error) {
 
 func (d *UserDAOImpl) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := d.db.Where("email = ?", email).First(&user).Error
+	err := d.db.Preload("Roles.Permissions").Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+func (d *UserDAOImpl) ExistsByEmail(email string) (bool, error) {
+	var count int64
+	err := d.db.Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	return count > 0, err
+}
+
 func (d *UserDAOImpl) List(offset, limit int) ([]*models.User, error) {
 	var users []*models.User
-	err := d.db.Offset(offset).Limit(limit).Find(&users).Error
+	err := d.db.Preload("Roles").Offset(offset).Limit(limit).Find(&users).Error
 	return users, err
 }
+
+func (d *UserDAOImpl) UpdateProfile(id uint, name, email string, emailVerifiedAt *time.Time) error {
+	return d.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]any{
+		"name":              name,
+		"email":             email,
+		"email_verified_at": emailVerifiedAt,
+	}).Error
+}
+
+func (d *UserDAOImpl) UpdatePassword(id uint, password string) error {
+	return d.db.Model(&models.User{}).Where("id = ?", id).Update("password", password).Error
+}
+
+func (d *UserDAOImpl) Delete(id uint) (bool, error) {
+	result := d.db.Delete(&models.User{}, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (d *UserDAOImpl) Restore(id uint) (bool, error) {
+	result := d.db.Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (d *UserDAOImpl) ReplaceRoles(user *models.User, roles []models.Role) error {
+	return d.db.Model(user).Association("Roles").Replace(roles)
+}
+
+func (d *UserDAOImpl) MarkEmailVerified(id uint, verifiedAt time.Time) error {
+	return d.db.Model(&models.User{}).Where("id = ?", id).Update("email_verified_at", verifiedAt).Error
+}
+
+func (d *UserDAOImpl) UpdateTOTP(id uint, secret string, enabled bool) error {
+	return d.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]any{
+		"totp_secret":       secret,
+		"totp_enabled":      enabled,
+		"totp_last_counter": 0,
+	}).Error
+}
+
+func (d *UserDAOImpl) AdvanceTOTPCounter(id uint, counter int64) (bool, error) {
+	result := d.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", id, counter).
+		Update("totp_last_counter", counter)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,31 @@
+package gorm
+
+import (
+	"evaframe/internal/models"
+	"evaframe/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentityDAOImpl 实现 service.UserIdentityDAO 接口
+type UserIdentityDAOImpl struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityDAO 返回接口类型
+func NewUserIdentityDAO(db *gorm.DB) service.UserIdentityDAO {
+	return &UserIdentityDAOImpl{db: db}
+}
+
+func (d *UserIdentityDAOImpl) Create(identity *models.UserIdentity) error {
+	return d.db.Create(identity).Error
+}
+
+func (d *UserIdentityDAOImpl) GetByProviderSubject(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := d.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
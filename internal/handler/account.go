@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"evaframe/internal/service"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/response"
+	"evaframe/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService *service.AccountService
+	val            *validator.Validator
+	logger         *logger.Logger
+}
+
+func NewAccountHandler(accountService *service.AccountService, validator *validator.Validator, logger *logger.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		val:            validator,
+		logger:         logger,
+	}
+}
+
+type ActionTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+// SendVerification 为当前用户重新发送邮箱验证邮件
+func (h *AccountHandler) SendVerification(c *gin.Context) {
+	if err := h.accountService.ResendVerificationEmail(c.Request.Context()); err != nil {
+		response.Error(c, err, "发送验证邮件失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	var req ActionTokenRequest
+	if !h.bind(c, &req, "邮箱验证失败") {
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(req.Token); err != nil {
+		response.BadRequest(c, err, "邮箱验证失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// ForgotPassword 无论邮箱是否注册都返回成功
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if !h.bind(c, &req, "发送重置邮件失败") {
+		return
+	}
+
+	h.accountService.RequestPasswordReset(c.Request.Context(), req.Email)
+	response.Success(c, nil)
+}
+
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if !h.bind(c, &req, "重置密码失败") {
+		return
+	}
+
+	if err := h.accountService.ResetPassword(req.Token, req.Password); err != nil {
+		response.BadRequest(c, err, "重置密码失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *AccountHandler) bind(c *gin.Context, req any, message string) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err, message)
+		return false
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(req); err != nil {
+		response.BadRequest(c, err, message)
+		return false
+	}
+	return true
+}
+
+func (h *AccountHandler) RegisterRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	// 公开路由
+	api.POST("/email/verify", h.VerifyEmail)
+	api.POST("/password/forgot", h.ForgotPassword)
+	api.POST("/password/reset", h.ResetPassword)
+
+	// 需要认证的路由
+	api.POST("/email/verify/send", authMiddleware, h.SendVerification)
+}
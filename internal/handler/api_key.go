@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"evaframe/internal/service"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/response"
+	"evaframe/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+	val           *validator.Validator
+	logger        *logger.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, validator *validator.Validator, logger *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		val:           validator,
+		logger:        logger,
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"omitempty,dive,required,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Create 创建 API 密钥，完整密钥只在响应中出现这一次
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, "创建 API 密钥失败")
+		return
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(&req); err != nil {
+		response.BadRequest(c, err, "创建 API 密钥失败")
+		return
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		response.Error(c, err, "创建 API 密钥失败")
+		return
+	}
+
+	response.Success(c, key)
+}
+
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		response.Error(c, err, "获取 API 密钥列表失败")
+		return
+	}
+
+	response.Success(c, keys)
+}
+
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, err, "无效的密钥ID")
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			response.Abort404(c, "API 密钥不存在")
+			return
+		}
+		response.Error(c, err, "吊销 API 密钥失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *APIKeyHandler) RegisterRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	// 需要认证的路由
+	auth := api.Group("/api-keys", authMiddleware)
+	{
+		auth.POST("", h.Create)
+		auth.GET("", h.List)
+		auth.DELETE("/:id", h.Revoke)
+	}
+}
@@ -2,4 +2,12 @@ package handler
 
 import "github.com/google/wire"
 
-var ProviderSet = wire.NewSet(NewUserHandler)
+var ProviderSet = wire.NewSet(
+	NewUserHandler,
+	NewJWKSHandler,
+	NewMFAHandler,
+	NewAccountHandler,
+	NewAPIKeyHandler,
+	NewOIDCHandler,
+	NewOAuthHandler,
+)
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler 发布 JWT 验签公钥，供其他服务验证 EvaFrame 签发的令牌
+type JWKSHandler struct {
+	jwt *jwt.JWT
+}
+
+func NewJWKSHandler(jwt *jwt.JWT) *JWKSHandler {
+	return &JWKSHandler{jwt: jwt}
+}
+
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	set, err := h.jwt.JWKS()
+	if err != nil {
+		response.InternalError(c, "获取公钥失败")
+		return
+	}
+
+	// JWKS 为标准格式，不使用统一响应结构包装
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
+
+func (h *JWKSHandler) RegisterRoutes(router gin.IRouter) {
+	router.GET("/.well-known/jwks.json", h.GetJWKS)
+}
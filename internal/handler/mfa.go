@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"evaframe/internal/service"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/response"
+	"evaframe/pkg/session"
+	"evaframe/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mfaLockedMessage 连续验证码错误被锁定时的提示
+const mfaLockedMessage = "验证码错误次数过多，请稍后再试"
+
+type MFAHandler struct {
+	mfaService *service.MFAService
+	sessions   *session.Manager
+	val        *validator.Validator
+	logger     *logger.Logger
+}
+
+func NewMFAHandler(mfaService *service.MFAService, sessions *session.Manager, validator *validator.Validator, logger *logger.Logger) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+		sessions:   sessions,
+		val:        validator,
+		logger:     logger,
+	}
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// Login 两步登录的第二步：用临时令牌和验证码（或恢复码）换取正式令牌或会话
+func (h *MFAHandler) Login(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, "登录失败")
+		return
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(&req); err != nil {
+		response.BadRequest(c, err, "登录失败")
+		return
+	}
+
+	login, err := h.mfaService.CompleteLogin(req.MFAToken, req.Code)
+	if err != nil {
+		if abortIfLocked(c, err, mfaLockedMessage) {
+			return
+		}
+		response.Unauthorized(c, "验证码或临时令牌无效")
+		return
+	}
+
+	respondLogin(c, h.sessions, login)
+}
+
+func (h *MFAHandler) SetupTOTP(c *gin.Context) {
+	setup, err := h.mfaService.SetupTOTP(c.Request.Context())
+	if err != nil {
+		response.Error(c, err, "生成两步验证密钥失败")
+		return
+	}
+
+	response.Success(c, setup)
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	var req MFACodeRequest
+	if !h.bindCode(c, &req, "启用两步验证失败") {
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmTOTP(c.Request.Context(), req.Code)
+	if err != nil {
+		response.Error(c, err, "启用两步验证失败")
+		return
+	}
+
+	response.Success(c, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	var req MFACodeRequest
+	if !h.bindCode(c, &req, "关闭两步验证失败") {
+		return
+	}
+
+	if err := h.mfaService.DisableTOTP(c.Request.Context(), req.Code); err != nil {
+		if abortIfLocked(c, err, mfaLockedMessage) {
+			return
+		}
+		response.Error(c, err, "关闭两步验证失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if !h.bindCode(c, &req, "生成恢复码失败") {
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.Request.Context(), req.Code)
+	if err != nil {
+		if abortIfLocked(c, err, mfaLockedMessage) {
+			return
+		}
+		response.Error(c, err, "生成恢复码失败")
+		return
+	}
+
+	response.Success(c, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) bindCode(c *gin.Context, req *MFACodeRequest, message string) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		response.BadRequest(c, err, message)
+		return false
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(req); err != nil {
+		response.BadRequest(c, err, message)
+		return false
+	}
+	return true
+}
+
+func (h *MFAHandler) RegisterRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	// 公开路由
+	api.POST("/login/mfa", h.Login)
+
+	// 需要认证的路由
+	auth := api.Group("/mfa", authMiddleware)
+	{
+		auth.POST("/totp/setup", h.SetupTOTP)
+		auth.POST("/totp/confirm", h.ConfirmTOTP)
+		auth.POST("/totp/disable", h.DisableTOTP)
+		auth.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	}
+}
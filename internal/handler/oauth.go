@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"evaframe/internal/consts"
+	"evaframe/internal/service"
+	"evaframe/pkg/config"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/middleware"
+	"evaframe/pkg/response"
+	"evaframe/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthHandler OAuth2 授权服务器
+//
+// 客户端管理和用户授权位于 /api/v1 下，使用统一响应结构；
+// 令牌、内省和吊销端点面向 OAuth2 客户端，按 RFC 使用表单请求和标准错误格式。
+type OAuthHandler struct {
+	oauthService *service.OAuthService
+	config       *config.Config
+	val          *validator.Validator
+	logger       *logger.Logger
+}
+
+func NewOAuthHandler(oauthService *service.OAuthService, cfg *config.Config, validator *validator.Validator, logger *logger.Logger) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+		config:       cfg,
+		val:          validator,
+		logger:       logger,
+	}
+}
+
+type RegisterClientRequest struct {
+	Name         string   `json:"name" validate:"required,max=100"`
+	Public       bool     `json:"public"`
+	RedirectURIs []string `json:"redirect_uris" validate:"omitempty,dive,required,url,max=500"`
+	GrantTypes   []string `json:"grant_types" validate:"omitempty,dive,oneof=authorization_code client_credentials"`
+	Scopes       []string `json:"scopes" validate:"omitempty,dive,required,max=100"`
+}
+
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type" validate:"required"`
+	ClientID            string `json:"client_id" validate:"required"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state" validate:"max=500"`
+	CodeChallenge       string `json:"code_challenge" validate:"omitempty,min=43,max=128"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+// RegisterClient 注册客户端，机密客户端的密钥只在响应中出现这一次
+func (h *OAuthHandler) RegisterClient(c *gin.Context) {
+	var req RegisterClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, "注册客户端失败")
+		return
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(&req); err != nil {
+		response.BadRequest(c, err, "注册客户端失败")
+		return
+	}
+
+	client, err := h.oauthService.RegisterClient(c.Request.Context(), &service.ClientRegistration{
+		Name:         req.Name,
+		Public:       req.Public,
+		RedirectURIs: req.RedirectURIs,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+	})
+	if err != nil {
+		response.Error(c, err, "注册客户端失败")
+		return
+	}
+
+	response.Success(c, client)
+}
+
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	clients, err := h.oauthService.ListClients()
+	if err != nil {
+		response.Error(c, err, "获取客户端列表失败")
+		return
+	}
+
+	response.Success(c, clients)
+}
+
+func (h *OAuthHandler) DeleteClient(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, err, "无效的客户端ID")
+		return
+	}
+
+	if err := h.oauthService.DeleteClient(uint(id)); err != nil {
+		if errors.Is(err, service.ErrOAuthClientNotFound) {
+			response.Abort404(c, "客户端不存在")
+			return
+		}
+		response.Error(c, err, "删除客户端失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// Authorize 当前用户同意授权，返回携带授权码（或错误）的客户端回调地址，由前端完成跳转
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	var req AuthorizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, "授权失败")
+		return
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(&req); err != nil {
+		response.BadRequest(c, err, "授权失败")
+		return
+	}
+
+	redirectURL, err := h.oauthService.Authorize(c.Request.Context(), &service.AuthorizeRequest{
+		ResponseType:        req.ResponseType,
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	})
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if errors.As(err, &oauthErr) {
+			response.BadRequest(c, err, "授权失败")
+			return
+		}
+		response.Error(c, err, "授权失败")
+		return
+	}
+
+	response.Success(c, gin.H{"redirect_uri": redirectURL})
+}
+
+// Token 令牌端点（RFC 6749 3.2）
+func (h *OAuthHandler) Token(c *gin.Context) {
+	clientID, clientSecret := clientCredentials(c)
+
+	token, err := h.oauthService.Token(&service.TokenRequest{
+		GrantType:    c.PostForm("grant_type"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Code:         c.PostForm("code"),
+		RedirectURI:  c.PostForm("redirect_uri"),
+		CodeVerifier: c.PostForm("code_verifier"),
+		Scope:        c.PostForm("scope"),
+	})
+	if err != nil {
+		h.abortOAuth(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, token)
+}
+
+// Introspect 令牌内省端点（RFC 7662）
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	clientID, clientSecret := clientCredentials(c)
+
+	result, err := h.oauthService.Introspect(clientID, clientSecret, c.PostForm("token"))
+	if err != nil {
+		h.abortOAuth(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, result)
+}
+
+// Revoke 令牌吊销端点（RFC 7009），无论令牌是否有效都返回 200
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	clientID, clientSecret := clientCredentials(c)
+
+	if err := h.oauthService.Revoke(clientID, clientSecret, c.PostForm("token")); err != nil {
+		h.abortOAuth(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Metadata 授权服务器元数据（RFC 8414）
+func (h *OAuthHandler) Metadata(c *gin.Context) {
+	base := h.config.BaseURL()
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"issuer":                                base,
+		"authorization_endpoint":                base + "/api/v1/oauth/authorize",
+		"token_endpoint":                        base + "/oauth/token",
+		"introspection_endpoint":                base + "/oauth/introspect",
+		"revocation_endpoint":                   base + "/oauth/revoke",
+		"jwks_uri":                              base + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{service.GrantAuthorizationCode, service.GrantClientCredentials},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+	})
+}
+
+// abortOAuth 按 RFC 6749 5.2 返回错误，客户端认证失败返回 401
+func (h *OAuthHandler) abortOAuth(c *gin.Context, err error) {
+	c.Header("Cache-Control", "no-store")
+
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		h.logger.LogIf(err)
+		c.JSON(http.StatusInternalServerError, &service.OAuthError{Code: service.OAuthServerError})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == service.OAuthInvalidClient {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		status = http.StatusUnauthorized
+	}
+	c.JSON(status, oauthErr)
+}
+
+// clientCredentials 从 HTTP Basic 认证或表单参数中读取客户端凭据，
+// Basic 认证中的凭据按 RFC 6749 2.3.1 先经过表单编码
+func clientCredentials(c *gin.Context) (string, string) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+		return id, secret
+	}
+	return c.PostForm("client_id"), c.PostForm("client_secret")
+}
+
+func (h *OAuthHandler) RegisterRoutes(router gin.IRouter, api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	router.GET("/.well-known/oauth-authorization-server", h.Metadata)
+
+	// 面向客户端的端点，由客户端凭据认证
+	endpoints := router.Group("/oauth")
+	{
+		endpoints.POST("/token", h.Token)
+		endpoints.POST("/introspect", h.Introspect)
+		endpoints.POST("/revoke", h.Revoke)
+	}
+
+	// 需要认证的路由
+	auth := api.Group("/oauth", authMiddleware)
+	{
+		auth.POST("/authorize", h.Authorize)
+		auth.POST("/clients", middleware.RequirePermission(consts.PermOAuthClients), h.RegisterClient)
+		auth.GET("/clients", middleware.RequirePermission(consts.PermOAuthClients), h.ListClients)
+		auth.DELETE("/clients/:id", middleware.RequirePermission(consts.PermOAuthClients), h.DeleteClient)
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestClientCredentialsDecodesBasicAuth Basic 认证中的客户端凭据按 RFC 6749 2.3.1 经过表单编码
+func TestClientCredentialsDecodesBasicAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name               string
+		basic              bool
+		id, secret         string
+		wantID, wantSecret string
+	}{
+		{"basic", true, url.QueryEscape("my client"), url.QueryEscape("s3:cr%t+"), "my client", "s3:cr%t+"},
+		{"form", false, "my client", "s3:cr%t+", "my client", "s3:cr%t+"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"grant_type": {"client_credentials"}}
+			if !tt.basic {
+				form.Set("client_id", tt.id)
+				form.Set("client_secret", tt.secret)
+			}
+			req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.basic {
+				req.SetBasicAuth(tt.id, tt.secret)
+			}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+			if id, secret := clientCredentials(c); id != tt.wantID || secret != tt.wantSecret {
+				t.Fatalf("credentials = %q, %q, want %q, %q", id, secret, tt.wantID, tt.wantSecret)
+			}
+		})
+	}
+}
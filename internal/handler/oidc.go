@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"evaframe/internal/service"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/oidc"
+	"evaframe/pkg/response"
+	"evaframe/pkg/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcStateCookie 保存 state 的 Cookie，回调时与查询参数比对，防止登录 CSRF
+	oidcStateCookie = "oidc_state"
+	// oidcStateMaxAge 与服务端保存登录上下文的时长一致
+	oidcStateMaxAge = 10 * time.Minute
+)
+
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+	sessions    *session.Manager
+	logger      *logger.Logger
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService, sessions *session.Manager, logger *logger.Logger) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		sessions:    sessions,
+		logger:      logger,
+	}
+}
+
+// Login 跳转到提供方登录页，可通过 login_hint 预填邮箱
+func (h *OIDCHandler) Login(c *gin.Context) {
+	login, err := h.oidcService.BeginLogin(c.Request.Context(), c.Param("provider"), c.Query("login_hint"))
+	if err != nil {
+		if errors.Is(err, oidc.ErrUnknownProvider) {
+			response.Abort404(c, "登录方式不存在")
+			return
+		}
+		response.Error(c, err, "跳转第三方登录失败")
+		return
+	}
+
+	h.setStateCookie(c, login.State, int(oidcStateMaxAge.Seconds()))
+	c.Redirect(http.StatusFound, login.AuthURL)
+}
+
+// Callback 提供方登录完成后的回调，返回与密码登录相同的结果
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	state := c.Query("state")
+	cookie, _ := c.Cookie(oidcStateCookie)
+	h.setStateCookie(c, "", -1)
+
+	if errCode := c.Query("error"); errCode != "" {
+		response.BadRequest(c, errors.New(errCode+": "+c.Query("error_description")), "第三方登录失败")
+		return
+	}
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie)) != 1 {
+		response.BadRequest(c, service.ErrOIDCInvalidState, "第三方登录失败")
+		return
+	}
+
+	login, err := h.oidcService.CompleteLogin(c.Request.Context(), c.Param("provider"), state, c.Query("code"))
+	if err != nil {
+		response.Error(c, err, "第三方登录失败")
+		return
+	}
+
+	respondLogin(c, h.sessions, login)
+}
+
+func (h *OIDCHandler) setStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, "/api/v1/oidc", "", c.Request.TLS != nil, true)
+}
+
+func (h *OIDCHandler) RegisterRoutes(api *gin.RouterGroup) {
+	// 公开路由
+	api.GET("/oidc/:provider/login", h.Login)
+	api.GET("/oidc/:provider/callback", h.Callback)
+}
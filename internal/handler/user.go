@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"strconv"
 
+	"evaframe/internal/consts"
 	"evaframe/internal/service"
+	"evaframe/pkg/auth"
 	"evaframe/pkg/logger"
+	"evaframe/pkg/middleware"
 	"evaframe/pkg/response"
+	"evaframe/pkg/session"
 	"evaframe/pkg/validator"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +19,15 @@ import (
 
 type UserHandler struct {
 	userService *service.UserService
+	sessions    *session.Manager
 	val         *validator.Validator
 	logger      *logger.Logger
 }
 
-func NewUserHandler(userService *service.UserService, validator *validator.Validator, logger *logger.Logger) *UserHandler {
+func NewUserHandler(userService *service.UserService, sessions *session.Manager, validator *validator.Validator, logger *logger.Logger) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		sessions:    sessions,
 		val:         validator,
 		logger:      logger,
 	}
@@ -60,8 +68,32 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	User  any    `json:"user"`
-	Token string `json:"token"`
+	User any `json:"user"`
+	*service.TokenPair
+	CSRFToken   string `json:"csrf_token,omitempty"` // 会话模式下非 GET 请求需要在请求头中携带
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+// respondLogin 返回登录结果。会话模式下先删除请求携带的旧会话再写入新会话的 Cookie，
+// 登录前后的会话 ID 不同，防止会话固定攻击
+func respondLogin(c *gin.Context, sessions *session.Manager, login *service.LoginResult) {
+	result := LoginResponse{
+		User:        login.User,
+		TokenPair:   login.Tokens,
+		MFARequired: login.MFARequired,
+		MFAToken:    login.MFAToken,
+	}
+
+	if login.Session != nil {
+		if err := sessions.DestroyToken(sessions.Token(c)); err != nil {
+			logger.L().LogIf(err)
+		}
+		sessions.SetCookie(c, login.SessionToken, login.Session)
+		result.CSRFToken = login.Session.CSRFToken
+	}
+
+	response.Success(c, result)
 }
 
 func (h *UserHandler) Login(c *gin.Context) {
@@ -78,29 +110,101 @@ func (h *UserHandler) Login(c *gin.Context) {
 	}
 
 	// 调用业务逻辑层
-	user, token, err := h.userService.AuthenticateUser(req.Email, req.Password)
+	login, err := h.userService.AuthenticateUser(req.Email, req.Password, c.ClientIP())
 	if err != nil {
+		if abortIfLocked(c, err, "密码错误次数过多，请稍后再试") {
+			return
+		}
 		response.Error(c, err, "登录失败")
 		return
 	}
 
-	// 构造响应
-	result := LoginResponse{
-		User:  user,
-		Token: token,
+	respondLogin(c, h.sessions, login)
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, "刷新令牌失败")
+		return
 	}
 
-	response.Success(c, result)
+	// 验证请求数据
+	if err := h.val.Validate(&req); err != nil {
+		response.BadRequest(c, err, "刷新令牌失败")
+		return
+	}
+
+	tokens, err := h.userService.RefreshToken(req.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+		response.Unauthorized(c, "刷新令牌无效或已过期")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, "刷新令牌失败")
+		return
+	}
+
+	response.Success(c, tokens)
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *UserHandler) Logout(c *gin.Context) {
+	// 请求体可选，携带刷新令牌时一并吊销
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, err, "退出登录失败")
+			return
+		}
+	}
+
+	if err := h.userService.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		response.Error(c, err, "退出登录失败")
+		return
+	}
+
+	if principal, ok := auth.FromContext(c); ok && principal.IsSession() {
+		h.sessions.ClearCookie(c)
+	}
+
+	response.Success(c, nil)
+}
+
+// GetSession 返回当前会话的 CSRF 令牌和过期时间，供页面刷新后重新获取
+func (h *UserHandler) GetSession(c *gin.Context) {
+	principal, ok := auth.FromContext(c)
+	if !ok || !principal.IsSession() {
+		response.Abort404(c, "当前请求未使用会话登录")
+		return
+	}
+
+	sess, err := h.sessions.Get(principal.SessionID)
+	if err != nil {
+		response.Error(c, err, "获取会话失败")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"csrf_token": sess.CSRFToken,
+		"expires_at": sess.ExpiresAt,
+	})
 }
 
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	if _, ok := auth.FromContext(c); !ok {
 		response.Unauthorized(c, "user not authenticated")
 		return
 	}
 
-	user, err := h.userService.GetUserByID(userID.(uint))
+	user, err := h.userService.GetCurrentUser(c.Request.Context())
 	if err != nil {
 		response.Error(c, err, "获取用户信息失败")
 		return
@@ -109,6 +213,62 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	response.Success(c, user)
 }
 
+type UpdateProfileRequest struct {
+	Name  *string `json:"name" validate:"omitempty,min=4,max=10"`
+	Email *string `json:"email" validate:"omitempty,email"`
+}
+
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, "修改用户信息失败")
+		return
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(&req); err != nil {
+		response.BadRequest(c, err, "修改用户信息失败")
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(c.Request.Context(), req.Name, req.Email)
+	if err != nil {
+		response.Error(c, err, "修改用户信息失败")
+		return
+	}
+
+	response.Success(c, user)
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, "修改密码失败")
+		return
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(&req); err != nil {
+		response.BadRequest(c, err, "修改密码失败")
+		return
+	}
+
+	if err := h.userService.ChangePassword(c.Request.Context(), req.CurrentPassword, req.NewPassword); err != nil {
+		if abortIfLocked(c, err, "密码错误次数过多，请稍后再试") {
+			return
+		}
+		response.Error(c, err, "修改密码失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
 func (h *UserHandler) ListUsers(c *gin.Context) {
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
@@ -122,15 +282,105 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	response.Success(c, users)
 }
 
+type AssignRolesRequest struct {
+	Roles []string `json:"roles" validate:"required,dive,required"`
+}
+
+func (h *UserHandler) AssignRoles(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, err, "分配角色失败")
+		return
+	}
+
+	var req AssignRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err, "分配角色失败")
+		return
+	}
+
+	// 验证请求数据
+	if err := h.val.Validate(&req); err != nil {
+		response.BadRequest(c, err, "分配角色失败")
+		return
+	}
+
+	user, err := h.userService.AssignRoles(uint(id), req.Roles)
+	if err != nil {
+		response.Error(c, err, "分配角色失败")
+		return
+	}
+
+	response.Success(c, user)
+}
+
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, err, "删除用户失败")
+		return
+	}
+
+	if err := h.userService.DeleteUser(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Abort404(c, "用户不存在")
+			return
+		}
+		response.Error(c, err, "删除用户失败")
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, err, "恢复用户失败")
+		return
+	}
+
+	user, err := h.userService.RestoreUser(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			response.Abort404(c, "用户不存在或未被删除")
+			return
+		}
+		response.Error(c, err, "恢复用户失败")
+		return
+	}
+
+	response.Success(c, user)
+}
+
+// abortIfLocked 连续密码或验证码错误导致锁定时返回 429 和 Retry-After
+func abortIfLocked(c *gin.Context, err error, message string) bool {
+	var locked *service.LoginLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	response.TooManyRequests(c, message)
+	return true
+}
+
 func (h *UserHandler) RegisterRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
 	// 公开路由
 	api.POST("/register", h.Register)
 	api.POST("/login", h.Login)
+	api.POST("/token/refresh", h.RefreshToken)
 
 	// 需要认证的路由
 	auth := api.Group("/", authMiddleware)
 	{
+		auth.POST("/logout", h.Logout)
+		auth.GET("/session", h.GetSession)
 		auth.GET("/profile", h.GetProfile)
-		auth.GET("/users", h.ListUsers)
+		auth.PUT("/profile", h.UpdateProfile)
+		auth.PUT("/profile/password", h.ChangePassword)
+		auth.GET("/users", middleware.RequirePermission(consts.PermUsersList), h.ListUsers)
+		auth.PUT("/users/:id/roles", middleware.RequirePermission(consts.PermRolesAssign), h.AssignRoles)
+		auth.DELETE("/users/:id", middleware.RequirePermission(consts.PermUsersDelete), h.DeleteUser)
+		auth.POST("/users/:id/restore", middleware.RequirePermission(consts.PermUsersRestore), h.RestoreUser)
 	}
 }
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/internal/service"
+	"evaframe/pkg/config"
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/loginguard"
+	"evaframe/pkg/middleware"
+	"evaframe/pkg/revocation"
+	"evaframe/pkg/session"
+	"evaframe/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// fakeUserDAO 内存实现的 service.UserDAO
+type fakeUserDAO struct {
+	users map[uint]*models.User
+}
+
+func (d *fakeUserDAO) Create(user *models.User) error {
+	user.ID = uint(len(d.users) + 1)
+	d.users[user.ID] = user
+	return nil
+}
+
+func (d *fakeUserDAO) GetByID(id uint) (*models.User, error) {
+	if user, ok := d.users[id]; ok {
+		return user, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (d *fakeUserDAO) GetByEmail(email string) (*models.User, error) {
+	for _, user := range d.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (d *fakeUserDAO) ExistsByEmail(email string) (bool, error) {
+	_, err := d.GetByEmail(email)
+	return err == nil, nil
+}
+
+func (d *fakeUserDAO) List(offset, limit int) ([]*models.User, error) {
+	users := make([]*models.User, 0, len(d.users))
+	for _, user := range d.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (d *fakeUserDAO) UpdateProfile(id uint, name, email string, emailVerifiedAt *time.Time) error {
+	d.users[id].Name = name
+	d.users[id].Email = email
+	d.users[id].EmailVerifiedAt = emailVerifiedAt
+	return nil
+}
+
+func (d *fakeUserDAO) Delete(id uint) (bool, error) {
+	if _, ok := d.users[id]; !ok {
+		return false, nil
+	}
+	delete(d.users, id)
+	return true, nil
+}
+
+func (d *fakeUserDAO) Restore(id uint) (bool, error) {
+	return false, nil
+}
+
+func (d *fakeUserDAO) UpdatePassword(id uint, password string) error {
+	d.users[id].Password = password
+	return nil
+}
+
+func (d *fakeUserDAO) ReplaceRoles(user *models.User, roles []models.Role) error {
+	user.Roles = roles
+	return nil
+}
+
+func (d *fakeUserDAO) MarkEmailVerified(id uint, verifiedAt time.Time) error {
+	d.users[id].EmailVerifiedAt = &verifiedAt
+	return nil
+}
+
+func (d *fakeUserDAO) UpdateTOTP(id uint, secret string, enabled bool) error {
+	d.users[id].TOTPSecret = secret
+	d.users[id].TOTPEnabled = enabled
+	d.users[id].TOTPLastCounter = 0
+	return nil
+}
+
+func (d *fakeUserDAO) AdvanceTOTPCounter(id uint, counter int64) (bool, error) {
+	if d.users[id].TOTPLastCounter >= counter {
+		return false, nil
+	}
+	d.users[id].TOTPLastCounter = counter
+	return true, nil
+}
+
+// newTestRouter 使用真实的认证中间件和路由注册，构造只依赖内存实现的路由
+func newTestRouter(t *testing.T, dao *fakeUserDAO) (*gin.Engine, *jwt.JWT) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var cfg config.Config
+	cfg.JWT.Secret = "test-secret"
+	j, err := jwt.NewJWT(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := &logger.Logger{Logger: zap.NewNop()}
+	store := revocation.NewMemoryStore()
+	sessions, err := session.NewManager(&cfg, session.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	userService := service.NewUserService(&cfg, log, j, nil, nil, nil, nil, loginguard.NewGuard(&cfg), store, dao, nil)
+	h := NewUserHandler(userService, sessions, validator.NewValidator(), log)
+
+	authMiddleware, err := middleware.NewAuthMiddleware(&cfg, j, store, nil, sessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	h.RegisterRoutes(router.Group("/api/v1"), gin.HandlerFunc(authMiddleware))
+	return router, j
+}
+
+// TestGetProfileUsesPrincipalFromAuthMiddleware 固定认证中间件与 UserHandler 的约定，
+// 防止两边再次使用不一致的上下文键导致 /profile 永远返回 401
+func TestGetProfileUsesPrincipalFromAuthMiddleware(t *testing.T) {
+	dao := &fakeUserDAO{users: map[uint]*models.User{
+		3: {ID: 3, Name: "alice", Email: "a@example.com"},
+	}}
+	router, j := newTestRouter(t, dao)
+
+	token, err := j.GenerateToken(jwt.Identity{UserID: 3, Email: "a@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Data models.User `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Data.ID != 3 || body.Data.Email != "a@example.com" {
+		t.Fatalf("profile = %+v, want user 3", body.Data)
+	}
+}
+
+func TestGetProfileRequiresAuthentication(t *testing.T) {
+	router, _ := newTestRouter(t, &fakeUserDAO{users: map[uint]*models.User{}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/profile", nil))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestLogoutRevokesCurrentToken(t *testing.T) {
+	dao := &fakeUserDAO{users: map[uint]*models.User{
+		3: {ID: 3, Name: "alice", Email: "a@example.com"},
+	}}
+	router, j := newTestRouter(t, dao)
+
+	token, err := j.GenerateToken(jwt.Identity{UserID: 3, Email: "a@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		req := httptest.NewRequest("POST", "/api/v1/logout", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("logout #%d status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+}
+
+func TestUpdateProfileOnlyChangesProvidedFields(t *testing.T) {
+	dao := &fakeUserDAO{users: map[uint]*models.User{
+		3: {ID: 3, Name: "alice", Email: "a@example.com"},
+	}}
+	router, j := newTestRouter(t, dao)
+
+	token, err := j.GenerateToken(jwt.Identity{UserID: 3, Email: "a@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("PUT", "/api/v1/profile", strings.NewReader(`{"name":"alicia"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body = %s", w.Code, w.Body.String())
+	}
+	if got := dao.users[3]; got.Name != "alicia" || got.Email != "a@example.com" {
+		t.Fatalf("user = %+v, want name changed and email kept", got)
+	}
+}
@@ -0,0 +1,160 @@
+package migrations
+
+import (
+	"time"
+
+	"evaframe/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 基线迁移：引入版本化迁移前由 AutoMigrate 创建的全部表。
+// 结构体是当时模型的副本，之后模型的变化应该写成新的迁移，不要修改这里。
+// 在已有数据库上执行时 AutoMigrate 只补齐缺少的表和列，不影响已有数据
+func init() {
+	register(migrate.Migration{
+		Version: "20261018000000",
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(baselineModels()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			models := baselineModels()
+			// 先删除多对多关联表
+			tables := []any{"user_roles", "role_permissions"}
+			for i := len(models) - 1; i >= 0; i-- {
+				tables = append(tables, models[i])
+			}
+			return tx.Migrator().DropTable(tables...)
+		},
+	})
+}
+
+func baselineModels() []any {
+	type Permission struct {
+		ID          uint   `gorm:"primarykey"`
+		Name        string `gorm:"size:100;uniqueIndex;not null"`
+		Description string `gorm:"size:255"`
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+	}
+	type Role struct {
+		ID          uint         `gorm:"primarykey"`
+		Name        string       `gorm:"size:50;uniqueIndex;not null"`
+		Description string       `gorm:"size:255"`
+		Permissions []Permission `gorm:"many2many:role_permissions;"`
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+	}
+	type User struct {
+		ID              uint   `gorm:"primarykey"`
+		Name            string `gorm:"size:100;not null"`
+		Email           string `gorm:"size:100;uniqueIndex;not null"`
+		Password        string `gorm:"size:255;not null"`
+		Roles           []Role `gorm:"many2many:user_roles;"`
+		EmailVerifiedAt *time.Time
+		TOTPSecret      string `gorm:"size:64"`
+		TOTPEnabled     bool   `gorm:"not null;default:false"`
+		TOTPLastCounter int64  `gorm:"not null;default:0"`
+		CreatedAt       time.Time
+		UpdatedAt       time.Time
+		DeletedAt       gorm.DeletedAt `gorm:"index"`
+	}
+	type RefreshToken struct {
+		ID        uint      `gorm:"primarykey"`
+		UserID    uint      `gorm:"index;not null"`
+		FamilyID  string    `gorm:"size:64;index;not null"`
+		TokenHash string    `gorm:"size:64;uniqueIndex;not null"`
+		ExpiresAt time.Time `gorm:"not null"`
+		UsedAt    *time.Time
+		RevokedAt *time.Time
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+	type RecoveryCode struct {
+		ID        uint   `gorm:"primarykey"`
+		UserID    uint   `gorm:"index;not null"`
+		CodeHash  string `gorm:"size:64;not null"`
+		UsedAt    *time.Time
+		CreatedAt time.Time
+	}
+	type ActionToken struct {
+		ID        uint      `gorm:"primarykey"`
+		UserID    uint      `gorm:"index;not null"`
+		Purpose   string    `gorm:"size:32;not null"`
+		TokenID   string    `gorm:"size:64;uniqueIndex;not null"`
+		ExpiresAt time.Time `gorm:"not null"`
+		UsedAt    *time.Time
+		CreatedAt time.Time
+	}
+	type APIKey struct {
+		ID         uint     `gorm:"primarykey"`
+		UserID     uint     `gorm:"index;not null"`
+		Name       string   `gorm:"size:100;not null"`
+		Prefix     string   `gorm:"size:16;not null"`
+		KeyHash    string   `gorm:"size:64;uniqueIndex;not null"`
+		Scopes     []string `gorm:"serializer:json"`
+		ExpiresAt  *time.Time
+		LastUsedAt *time.Time
+		RevokedAt  *time.Time
+		CreatedAt  time.Time
+		UpdatedAt  time.Time
+	}
+	type UserIdentity struct {
+		ID        uint   `gorm:"primarykey"`
+		UserID    uint   `gorm:"index;not null"`
+		Provider  string `gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject"`
+		Subject   string `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject"`
+		Email     string `gorm:"size:100"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+	type OAuthClient struct {
+		ID           uint     `gorm:"primarykey"`
+		ClientID     string   `gorm:"size:64;uniqueIndex;not null"`
+		SecretHash   string   `gorm:"size:64"`
+		Name         string   `gorm:"size:100;not null"`
+		Public       bool     `gorm:"not null;default:false"`
+		RedirectURIs []string `gorm:"serializer:json"`
+		GrantTypes   []string `gorm:"serializer:json"`
+		Scopes       []string `gorm:"serializer:json"`
+		OwnerID      uint     `gorm:"index;not null"`
+		CreatedAt    time.Time
+		UpdatedAt    time.Time
+	}
+	type OAuthAuthorizationCode struct {
+		ID             uint     `gorm:"primarykey"`
+		CodeHash       string   `gorm:"size:64;uniqueIndex;not null"`
+		ClientID       string   `gorm:"size:64;index;not null"`
+		UserID         uint     `gorm:"index;not null"`
+		RedirectURI    string   `gorm:"size:500;not null"`
+		Scopes         []string `gorm:"serializer:json"`
+		CodeChallenge  string   `gorm:"size:128"`
+		ExpiresAt      time.Time
+		UsedAt         *time.Time
+		TokenID        string `gorm:"size:64"`
+		TokenExpiresAt *time.Time
+		CreatedAt      time.Time
+	}
+	type RevokedToken struct {
+		JTI       string    `gorm:"primarykey;size:64"`
+		ExpiresAt time.Time `gorm:"index;not null"`
+		CreatedAt time.Time
+	}
+	type Session struct {
+		ID          string   `gorm:"primarykey;size:64"`
+		UserID      uint     `gorm:"index;not null"`
+		Email       string   `gorm:"size:255"`
+		Roles       []string `gorm:"serializer:json"`
+		Permissions []string `gorm:"serializer:json"`
+		CSRFToken   string   `gorm:"size:64;not null"`
+		CreatedAt   time.Time
+		LastSeenAt  time.Time
+		ExpiresAt   time.Time `gorm:"index;not null"`
+	}
+
+	return []any{
+		&User{}, &RefreshToken{}, &Role{}, &Permission{}, &RecoveryCode{}, &ActionToken{},
+		&APIKey{}, &UserIdentity{}, &OAuthClient{}, &OAuthAuthorizationCode{}, &RevokedToken{}, &Session{},
+	}
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"evaframe/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 授权码记录授权请求是否显式携带了 redirect_uri，兑换时据此要求完全一致
+func init() {
+	type OAuthAuthorizationCode struct {
+		RedirectURIProvided bool `gorm:"not null;default:false"`
+	}
+
+	register(migrate.Migration{
+		Version: "20261019000000",
+		Name:    "add_oauth_code_redirect_uri_provided",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().AddColumn(&OAuthAuthorizationCode{}, "RedirectURIProvided")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&OAuthAuthorizationCode{}, "RedirectURIProvided")
+		},
+	})
+}
@@ -0,0 +1,19 @@
+// Package migrations 代码中定义的数据库迁移。每个迁移一个文件，文件名与版本号一致，
+// 在 init 中调用 register 注册；简单的结构变更也可以写成 database.migrations_dir 下的 SQL 文件
+package migrations
+
+import (
+	"evaframe/pkg/migrate"
+)
+
+var registered []migrate.Migration
+
+// register 注册迁移，只在 init 中调用
+func register(m migrate.Migration) {
+	registered = append(registered, m)
+}
+
+// All 返回代码中注册的全部迁移
+func All() []migrate.Migration {
+	return append([]migrate.Migration(nil), registered...)
+}
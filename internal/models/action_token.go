@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"evaframe/pkg/registry"
+)
+
+func init() {
+	registry.RegisterModel(&ActionToken{})
+}
+
+// ActionToken 记录邮件链接中签发的一次性令牌（邮箱验证、重置密码）
+//
+// 令牌本身是带签名和有效期的 JWT，这里只保存其 jti，用于保证令牌只能使用一次。
+type ActionToken struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	Purpose   string     `gorm:"size:32;not null" json:"purpose"`
+	TokenID   string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
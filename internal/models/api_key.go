@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"evaframe/pkg/registry"
+)
+
+func init() {
+	registry.RegisterModel(&APIKey{})
+}
+
+// APIKey 个人 API 密钥，供 CI 和第三方集成代表用户调用接口
+//
+// 完整密钥只在创建时返回一次，数据库中只保存哈希和用于识别的前缀。
+// Scopes 为空时拥有用户的全部权限，否则只拥有其中用户仍然具备的权限。
+type APIKey struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`
+	KeyHash    string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"evaframe/pkg/registry"
+)
+
+func init() {
+	registry.RegisterModel(&OAuthClient{}, &OAuthAuthorizationCode{})
+}
+
+// OAuthClient 在授权服务器注册的 OAuth2 客户端
+//
+// 机密客户端的密钥只在注册时返回一次，数据库中只保存哈希；
+// 公开客户端（如单页应用、移动端）没有密钥，授权码模式必须使用 PKCE。
+type OAuthClient struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	ClientID     string    `gorm:"size:64;uniqueIndex;not null" json:"client_id"`
+	SecretHash   string    `gorm:"size:64" json:"-"`
+	Name         string    `gorm:"size:100;not null" json:"name"`
+	Public       bool      `gorm:"not null;default:false" json:"public"`
+	RedirectURIs []string  `gorm:"serializer:json" json:"redirect_uris"`
+	GrantTypes   []string  `gorm:"serializer:json" json:"grant_types"`
+	Scopes       []string  `gorm:"serializer:json" json:"scopes"`
+	OwnerID      uint      `gorm:"index;not null" json:"owner_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// OAuthAuthorizationCode 授权码，数据库中只保存哈希
+type OAuthAuthorizationCode struct {
+	ID          uint   `gorm:"primarykey"`
+	CodeHash    string `gorm:"size:64;uniqueIndex;not null"`
+	ClientID    string `gorm:"size:64;index;not null"`
+	UserID      uint   `gorm:"index;not null"`
+	RedirectURI string `gorm:"size:500;not null"`
+	// RedirectURIProvided 授权请求是否显式携带了 redirect_uri，是则兑换时必须携带相同的值（RFC 6749 4.1.3）
+	RedirectURIProvided bool     `gorm:"not null;default:false"`
+	Scopes              []string `gorm:"serializer:json"`
+	CodeChallenge       string   `gorm:"size:128"`
+	ExpiresAt           time.Time
+	UsedAt              *time.Time
+	// TokenID 用该授权码签发的访问令牌，授权码被重复使用时据此吊销
+	TokenID        string `gorm:"size:64"`
+	TokenExpiresAt *time.Time
+	CreatedAt      time.Time
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"evaframe/pkg/registry"
+)
+
+func init() {
+	registry.RegisterModel(&RecoveryCode{})
+}
+
+// RecoveryCode 两步验证恢复码，只保存哈希，每个恢复码只能使用一次
+type RecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"evaframe/pkg/registry"
+)
+
+func init() {
+	registry.RegisterModel(&RefreshToken{})
+}
+
+// RefreshToken 刷新令牌，数据库中只保存令牌的哈希
+//
+// 同一次登录派生出的所有刷新令牌属于同一个 FamilyID，
+// 每次刷新都会作废旧令牌并签发新令牌（轮换），
+// 已使用过的令牌再次出现时视为泄露，整个家族一并吊销。
+type RefreshToken struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID  string     `gorm:"size:64;index;not null" json:"family_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -3,15 +3,54 @@ package models
 import (
 	"time"
 
+	"evaframe/pkg/registry"
+
 	"gorm.io/gorm"
 )
 
+func init() {
+	registry.RegisterModel(&User{})
+}
+
 type User struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	Name      string         `gorm:"size:100;not null" json:"name" validate:"required,min=2,max=100"`
-	Email     string         `gorm:"size:100;uniqueIndex;not null" json:"email" validate:"required,email"`
-	Password  string         `gorm:"size:255;not null" json:"-" validate:"required,min=6"`
+	ID       uint   `gorm:"primarykey" json:"id"`
+	Name     string `gorm:"size:100;not null" json:"name" validate:"required,min=2,max=100"`
+	Email    string `gorm:"size:100;uniqueIndex;not null" json:"email" validate:"required,email"`
+	Password string `gorm:"size:255;not null" json:"-" validate:"required,min=6"`
+	Roles    []Role `gorm:"many2many:user_roles;" json:"roles,omitempty"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// TOTP 两步验证，TOTPSecret 在确认启用前即已写入
+	TOTPSecret      string `gorm:"size:64" json:"-"`
+	TOTPEnabled     bool   `gorm:"not null;default:false" json:"totp_enabled"`
+	TOTPLastCounter int64  `gorm:"not null;default:0" json:"-"` // 最近一次使用的时间步，用于防重放
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
+
+// RoleNames 返回用户的角色名列表
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// PermissionNames 返回用户所有角色的权限名列表（已去重），需预加载 Roles.Permissions
+func (u *User) PermissionNames() []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, role := range u.Roles {
+		for _, perm := range role.Permissions {
+			if !seen[perm.Name] {
+				seen[perm.Name] = true
+				names = append(names, perm.Name)
+			}
+		}
+	}
+	return names
+}
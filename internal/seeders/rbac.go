@@ -0,0 +1,55 @@
+package seeders
+
+import (
+	"evaframe/internal/consts"
+	"evaframe/internal/models"
+	"evaframe/pkg/auth"
+
+	"gorm.io/gorm"
+)
+
+// permissions 内置权限
+var permissions = []models.Permission{
+	{Name: auth.PermissionAll, Description: "全部权限"},
+	{Name: consts.PermUsersList, Description: "查看用户列表"},
+	{Name: consts.PermUsersDelete, Description: "删除用户"},
+	{Name: consts.PermUsersRestore, Description: "恢复已删除的用户"},
+	{Name: consts.PermRolesAssign, Description: "为用户分配角色"},
+	{Name: consts.PermOAuthClients, Description: "管理 OAuth2 客户端"},
+}
+
+// roles 内置角色及其权限
+var roles = map[string][]string{
+	consts.RoleAdmin: {auth.PermissionAll},
+	consts.RoleUser:  {},
+}
+
+// SeedRBAC 写入内置角色和权限，可重复执行
+func SeedRBAC(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		byName := make(map[string]models.Permission)
+		for _, p := range permissions {
+			perm := p
+			if err := tx.Where(models.Permission{Name: perm.Name}).Attrs(perm).FirstOrCreate(&perm).Error; err != nil {
+				return err
+			}
+			byName[perm.Name] = perm
+		}
+
+		for name, permNames := range roles {
+			role := models.Role{Name: name}
+			if err := tx.Where(models.Role{Name: name}).FirstOrCreate(&role).Error; err != nil {
+				return err
+			}
+
+			rolePerms := make([]models.Permission, 0, len(permNames))
+			for _, permName := range permNames {
+				rolePerms = append(rolePerms, byName[permName])
+			}
+			if err := tx.Model(&role).Association("Permissions").Replace(rolePerms); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
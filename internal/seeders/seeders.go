@@ -0,0 +1,33 @@
+// Package seeders 写入系统运行所需的初始数据
+package seeders
+
+import (
+	"fmt"
+	"strings"
+
+	"evaframe/pkg/registry"
+
+	"gorm.io/gorm"
+)
+
+// Run 确认已注册模型的表都已创建后，依次执行所有 seeder
+func Run(db *gorm.DB) error {
+	if err := checkTables(db); err != nil {
+		return err
+	}
+	return SeedRBAC(db)
+}
+
+// checkTables 模型已注册但表不存在，通常是新增模型后忘记添加迁移
+func checkTables(db *gorm.DB) error {
+	var missing []string
+	for _, m := range registry.Models() {
+		if !db.Migrator().HasTable(m.Value) {
+			missing = append(missing, m.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no table for registered models %s, generate a migration with migrate diff", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/auth"
+	"evaframe/pkg/config"
+	"evaframe/pkg/hasher"
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/mailer"
+)
+
+const (
+	// verifyEmailTTL 邮箱验证链接有效期
+	verifyEmailTTL = 24 * time.Hour
+	// resetPasswordTTL 重置密码链接有效期
+	resetPasswordTTL = time.Hour
+	// resetMailTimeout 后台发送重置密码邮件的超时时间
+	resetMailTimeout = time.Minute
+)
+
+var (
+	ErrInvalidActionToken   = errors.New("invalid or expired link")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+)
+
+// ActionTokenDAO 一次性令牌数据访问接口
+type ActionTokenDAO interface {
+	Create(token *models.ActionToken) error
+	// Consume 仅当令牌存在、用途匹配、未使用且未过期时将其标记为已使用，返回是否标记成功
+	Consume(tokenID, purpose string, usedAt time.Time) (bool, error)
+	// InvalidateAll 作废用户某一用途下所有未使用的令牌
+	InvalidateAll(userID uint, purpose string, usedAt time.Time) error
+}
+
+// AccountService 邮箱验证与找回密码
+type AccountService struct {
+	config         *config.Config
+	logger         *logger.Logger
+	jwt            *jwt.JWT
+	hasher         hasher.PasswordHasher
+	mailer         mailer.Mailer
+	userDAO        UserDAO
+	tokens         *TokenService
+	actionTokenDAO ActionTokenDAO
+}
+
+func NewAccountService(
+	config *config.Config,
+	logger *logger.Logger,
+	jwt *jwt.JWT,
+	hasher hasher.PasswordHasher,
+	mailer mailer.Mailer,
+	userDAO UserDAO,
+	tokens *TokenService,
+	actionTokenDAO ActionTokenDAO,
+) *AccountService {
+	return &AccountService{
+		config:         config,
+		logger:         logger,
+		jwt:            jwt,
+		hasher:         hasher,
+		mailer:         mailer,
+		userDAO:        userDAO,
+		tokens:         tokens,
+		actionTokenDAO: actionTokenDAO,
+	}
+}
+
+// SendVerificationEmail 向用户发送邮箱验证邮件，之前发出的验证链接随即失效
+func (s *AccountService) SendVerificationEmail(ctx context.Context, user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueActionToken(user, jwt.PurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      []string{user.Email},
+		Subject: "验证您的邮箱",
+		Body: fmt.Sprintf("%s，您好：\n\n请在 24 小时内打开以下链接完成邮箱验证：\n\n%s\n\n如果这不是您的操作，请忽略本邮件。\n",
+			user.Name, s.link("/verify-email", token)),
+	})
+}
+
+// ResendVerificationEmail 为当前用户重新发送邮箱验证邮件
+func (s *AccountService) ResendVerificationEmail(ctx context.Context) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	user, err := s.userDAO.GetByID(principal.UserID)
+	if err != nil {
+		return err
+	}
+	return s.SendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail 使用邮件中的令牌完成邮箱验证
+func (s *AccountService) VerifyEmail(token string) error {
+	user, err := s.consumeActionToken(token, jwt.PurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userDAO.MarkEmailVerified(user.ID, time.Now()); err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+
+	s.logger.InfoString("account", "email verified", user.Email)
+	return nil
+}
+
+// RequestPasswordReset 在后台发送重置密码邮件。无论邮箱是否注册、邮件是否发送成功，
+// 调用方得到的结果和耗时都相同，避免泄露用户是否注册；发送失败只记录日志
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) {
+	user, err := s.userDAO.GetByEmail(email)
+	if err != nil {
+		s.logger.InfoString("account", "password reset requested for unknown email", email)
+		return
+	}
+
+	// 请求结束后继续发送，不随请求取消
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resetMailTimeout)
+	go func() {
+		defer cancel()
+		s.logger.LogIf(s.sendPasswordReset(ctx, user))
+	}()
+}
+
+func (s *AccountService) sendPasswordReset(ctx context.Context, user *models.User) error {
+	token, err := s.issueActionToken(user, jwt.PurposeResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      []string{user.Email},
+		Subject: "重置您的密码",
+		Body: fmt.Sprintf("%s，您好：\n\n请在 1 小时内打开以下链接重置密码：\n\n%s\n\n如果这不是您的操作，请忽略本邮件，您的密码不会被修改。\n",
+			user.Name, s.link("/reset-password", token)),
+	})
+}
+
+// ResetPassword 使用邮件中的令牌设置新密码，并吊销该用户所有刷新令牌
+func (s *AccountService) ResetPassword(token, password string) error {
+	user, err := s.consumeActionToken(token, jwt.PurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+	if err := s.userDAO.UpdatePassword(user.ID, hashedPassword); err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+
+	// 作废其余未使用的重置链接，并让已登录的设备重新登录
+	now := time.Now()
+	if err := s.actionTokenDAO.InvalidateAll(user.ID, jwt.PurposeResetPassword, now); err != nil {
+		s.logger.LogIf(err)
+	}
+	if err := s.tokens.RevokeAllLogins(user.ID, ""); err != nil {
+		s.logger.LogIf(err)
+	}
+
+	s.logger.InfoString("account", "password reset", user.Email)
+	return nil
+}
+
+// issueActionToken 签发一次性令牌，同一用途下之前签发的令牌全部作废
+func (s *AccountService) issueActionToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	claims, token, err := s.jwt.GeneratePurposeToken(user.ID, user.Email, purpose, ttl)
+	if err != nil {
+		s.logger.LogIf(err)
+		return "", err
+	}
+
+	if err := s.actionTokenDAO.InvalidateAll(user.ID, purpose, time.Now()); err != nil {
+		s.logger.LogIf(err)
+		return "", err
+	}
+
+	err = s.actionTokenDAO.Create(&models.ActionToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	})
+	if err != nil {
+		s.logger.LogIf(err)
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeActionToken 校验签名、有效期和用途，并将令牌标记为已使用
+func (s *AccountService) consumeActionToken(token, purpose string) (*models.User, error) {
+	claims, err := s.jwt.ParsePurposeToken(token, purpose)
+	if err != nil {
+		return nil, ErrInvalidActionToken
+	}
+
+	consumed, err := s.actionTokenDAO.Consume(claims.ID, purpose, time.Now())
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidActionToken
+	}
+
+	// 令牌签发后邮箱被修改，则令牌失效
+	user, err := s.userDAO.GetByID(claims.UserID)
+	if err != nil || user.Email != claims.Email {
+		return nil, ErrInvalidActionToken
+	}
+	return user, nil
+}
+
+// link 生成邮件中的链接
+func (s *AccountService) link(path, token string) string {
+	return s.config.BaseURL() + path + "?token=" + url.QueryEscape(token)
+}
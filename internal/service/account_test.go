@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/hasher"
+	"evaframe/pkg/mailer"
+	"evaframe/pkg/session"
+)
+
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+type accountTest struct {
+	s       *AccountService
+	users   *fakeUserDAO
+	refresh *fakeRefreshTokenDAO
+	mailer  *mailer.MemoryMailer
+}
+
+func newAccountTest(t *testing.T) *accountTest {
+	t.Helper()
+	cfg := newTestConfig()
+	cfg.Server.PublicURL = "https://app.example.com"
+	sessions, err := session.NewManager(cfg, session.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j := newTestJWT(t, cfg)
+	users := newFakeUserDAO(&models.User{ID: 1, Name: "John", Email: "john@example.com"})
+	refresh := &fakeRefreshTokenDAO{}
+	tokens := NewTokenService(cfg, newTestLogger(), j, sessions, users, refresh)
+	m := mailer.NewMemoryMailer("noreply@example.com")
+	return &accountTest{
+		s:       NewAccountService(cfg, newTestLogger(), j, hasher.NewBcryptHasher(4), m, users, tokens, &fakeActionTokenDAO{}),
+		users:   users,
+		refresh: refresh,
+		mailer:  m,
+	}
+}
+
+// waitForMail 等待第 n 封邮件并取出其中链接的令牌，重置密码邮件在后台发送
+func (a *accountTest) waitForMail(t *testing.T, n int, path string) string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for len(a.mailer.Messages()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d messages, want %d", len(a.mailer.Messages()), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	msg := a.mailer.Messages()[n-1]
+	u, err := url.Parse(linkPattern.FindString(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != path || u.Query().Get("token") == "" {
+		t.Fatalf("link = %s, want %s with a token", u, path)
+	}
+	return u.Query().Get("token")
+}
+
+func TestVerifyEmail(t *testing.T) {
+	a := newAccountTest(t)
+
+	if err := a.s.SendVerificationEmail(context.Background(), a.users.users[1]); err != nil {
+		t.Fatal(err)
+	}
+	token := a.waitForMail(t, 1, "/verify-email")
+
+	if err := a.s.VerifyEmail(token); err != nil {
+		t.Fatal(err)
+	}
+	if a.users.users[1].EmailVerifiedAt == nil {
+		t.Fatal("email not marked as verified")
+	}
+	if err := a.s.SendVerificationEmail(context.Background(), a.users.users[1]); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Fatalf("err = %v, want ErrEmailAlreadyVerified", err)
+	}
+}
+
+// TestActionTokenSingleUse 令牌只能使用一次，重新发送后旧令牌失效，且不能跨用途使用
+func TestActionTokenSingleUse(t *testing.T) {
+	a := newAccountTest(t)
+	user := a.users.users[1]
+
+	if err := a.s.SendVerificationEmail(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	stale := a.waitForMail(t, 1, "/verify-email")
+	if err := a.s.SendVerificationEmail(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	token := a.waitForMail(t, 2, "/verify-email")
+
+	if err := a.s.ResetPassword(token, "newpassword"); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("reset with a verify token: err = %v, want ErrInvalidActionToken", err)
+	}
+	if err := a.s.VerifyEmail(stale); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("superseded token: err = %v, want ErrInvalidActionToken", err)
+	}
+	if err := a.s.VerifyEmail(token); err != nil {
+		t.Fatal(err)
+	}
+
+	user.EmailVerifiedAt = nil
+	if err := a.s.VerifyEmail(token); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("second use: err = %v, want ErrInvalidActionToken", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	a := newAccountTest(t)
+	a.refresh.tokens = []*models.RefreshToken{{ID: 1, UserID: 1, TokenHash: "hash"}}
+
+	a.s.RequestPasswordReset(context.Background(), "john@example.com")
+	token := a.waitForMail(t, 1, "/reset-password")
+
+	if err := a.s.ResetPassword(token, "newpassword"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := a.s.hasher.Verify(a.users.users[1].Password, "newpassword"); !ok {
+		t.Fatal("password not updated")
+	}
+	if a.refresh.tokens[0].RevokedAt == nil {
+		t.Fatal("refresh token not revoked after password reset")
+	}
+	if err := a.s.ResetPassword(token, "otherpassword"); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("second use: err = %v, want ErrInvalidActionToken", err)
+	}
+}
+
+// TestResetPasswordChangedEmail 令牌签发后邮箱被修改则失效
+func TestResetPasswordChangedEmail(t *testing.T) {
+	a := newAccountTest(t)
+
+	a.s.RequestPasswordReset(context.Background(), "john@example.com")
+	token := a.waitForMail(t, 1, "/reset-password")
+
+	a.users.users[1].Email = "other@example.com"
+	if err := a.s.ResetPassword(token, "newpassword"); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("err = %v, want ErrInvalidActionToken", err)
+	}
+}
+
+// TestRequestPasswordResetUnknownEmail 未注册的邮箱不发送邮件，调用方无法区分
+func TestRequestPasswordResetUnknownEmail(t *testing.T) {
+	a := newAccountTest(t)
+
+	a.s.RequestPasswordReset(context.Background(), "nobody@example.com")
+	// 已注册邮箱的邮件在后台发送，等它发出后再确认未注册邮箱没有邮件
+	a.s.RequestPasswordReset(context.Background(), "john@example.com")
+	a.waitForMail(t, 1, "/reset-password")
+
+	for _, msg := range a.mailer.Messages() {
+		if msg.To[0] != "john@example.com" {
+			t.Fatalf("mail sent to %v", msg.To)
+		}
+	}
+}
+
+// TestRequestPasswordResetOutlivesRequest 请求被取消后邮件仍然发送
+func TestRequestPasswordResetOutlivesRequest(t *testing.T) {
+	a := newAccountTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.s.RequestPasswordReset(ctx, "john@example.com")
+	cancel()
+	a.waitForMail(t, 1, "/reset-password")
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/config"
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// fakeUserDAO 内存实现的 UserDAO
+type fakeUserDAO struct {
+	users map[uint]*models.User
+}
+
+func newFakeUserDAO(users ...*models.User) *fakeUserDAO {
+	d := &fakeUserDAO{users: make(map[uint]*models.User)}
+	for _, user := range users {
+		d.users[user.ID] = user
+	}
+	return d
+}
+
+func (d *fakeUserDAO) Create(user *models.User) error {
+	user.ID = uint(len(d.users) + 1)
+	d.users[user.ID] = user
+	return nil
+}
+
+func (d *fakeUserDAO) GetByID(id uint) (*models.User, error) {
+	if user, ok := d.users[id]; ok {
+		return user, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (d *fakeUserDAO) GetByEmail(email string) (*models.User, error) {
+	for _, user := range d.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (d *fakeUserDAO) ExistsByEmail(email string) (bool, error) {
+	_, err := d.GetByEmail(email)
+	return err == nil, nil
+}
+
+func (d *fakeUserDAO) List(offset, limit int) ([]*models.User, error) {
+	users := make([]*models.User, 0, len(d.users))
+	for _, user := range d.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (d *fakeUserDAO) UpdateProfile(id uint, name, email string, emailVerifiedAt *time.Time) error {
+	d.users[id].Name = name
+	d.users[id].Email = email
+	d.users[id].EmailVerifiedAt = emailVerifiedAt
+	return nil
+}
+
+func (d *fakeUserDAO) UpdatePassword(id uint, password string) error {
+	d.users[id].Password = password
+	return nil
+}
+
+func (d *fakeUserDAO) Delete(id uint) (bool, error) {
+	if _, ok := d.users[id]; !ok {
+		return false, nil
+	}
+	delete(d.users, id)
+	return true, nil
+}
+
+func (d *fakeUserDAO) Restore(id uint) (bool, error) {
+	return false, nil
+}
+
+func (d *fakeUserDAO) ReplaceRoles(user *models.User, roles []models.Role) error {
+	user.Roles = roles
+	return nil
+}
+
+func (d *fakeUserDAO) MarkEmailVerified(id uint, verifiedAt time.Time) error {
+	d.users[id].EmailVerifiedAt = &verifiedAt
+	return nil
+}
+
+func (d *fakeUserDAO) UpdateTOTP(id uint, secret string, enabled bool) error {
+	d.users[id].TOTPSecret = secret
+	d.users[id].TOTPEnabled = enabled
+	d.users[id].TOTPLastCounter = 0
+	return nil
+}
+
+func (d *fakeUserDAO) AdvanceTOTPCounter(id uint, counter int64) (bool, error) {
+	if d.users[id].TOTPLastCounter >= counter {
+		return false, nil
+	}
+	d.users[id].TOTPLastCounter = counter
+	return true, nil
+}
+
+// fakeRecoveryCodeDAO 内存实现的 RecoveryCodeDAO
+type fakeRecoveryCodeDAO struct {
+	codes map[uint][]models.RecoveryCode
+}
+
+func (d *fakeRecoveryCodeDAO) Replace(userID uint, codes []models.RecoveryCode) error {
+	if d.codes == nil {
+		d.codes = make(map[uint][]models.RecoveryCode)
+	}
+	d.codes[userID] = codes
+	return nil
+}
+
+func (d *fakeRecoveryCodeDAO) Use(userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	for i := range d.codes[userID] {
+		code := &d.codes[userID][i]
+		if code.CodeHash == codeHash && code.UsedAt == nil {
+			code.UsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newTestConfig 使用 HS256 的最小配置
+func newTestConfig() *config.Config {
+	var cfg config.Config
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.AccessTTL = 15 * time.Minute
+	cfg.JWT.RefreshTTL = time.Hour
+	return &cfg
+}
+
+func newTestJWT(t *testing.T, cfg *config.Config) *jwt.JWT {
+	t.Helper()
+	j, err := jwt.NewJWT(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func newTestLogger() *logger.Logger {
+	return &logger.Logger{Logger: zap.NewNop()}
+}
+
+// fakeRefreshTokenDAO 内存实现的 RefreshTokenDAO
+type fakeRefreshTokenDAO struct {
+	tokens []*models.RefreshToken
+}
+
+func (d *fakeRefreshTokenDAO) Create(token *models.RefreshToken) error {
+	token.ID = uint(len(d.tokens) + 1)
+	d.tokens = append(d.tokens, token)
+	return nil
+}
+
+func (d *fakeRefreshTokenDAO) GetByHash(hash string) (*models.RefreshToken, error) {
+	for _, token := range d.tokens {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (d *fakeRefreshTokenDAO) MarkUsed(id uint, usedAt time.Time) (bool, error) {
+	token := d.tokens[id-1]
+	if token.UsedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &usedAt
+	return true, nil
+}
+
+func (d *fakeRefreshTokenDAO) RevokeFamily(familyID string, revokedAt time.Time) error {
+	for _, token := range d.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+func (d *fakeRefreshTokenDAO) RevokeByUser(userID uint, revokedAt time.Time) error {
+	for _, token := range d.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+// fakeRoleDAO 内存实现的 RoleDAO
+type fakeRoleDAO struct {
+	roles []models.Role
+}
+
+func (d *fakeRoleDAO) GetByNames(names []string) ([]models.Role, error) {
+	var roles []models.Role
+	for _, role := range d.roles {
+		if slices.Contains(names, role.Name) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// fakeOAuthClientDAO 内存实现的 OAuthClientDAO
+type fakeOAuthClientDAO struct {
+	clients []*models.OAuthClient
+}
+
+func (d *fakeOAuthClientDAO) Create(client *models.OAuthClient) error {
+	client.ID = uint(len(d.clients) + 1)
+	d.clients = append(d.clients, client)
+	return nil
+}
+
+func (d *fakeOAuthClientDAO) GetByClientID(clientID string) (*models.OAuthClient, error) {
+	for _, client := range d.clients {
+		if client.ClientID == clientID {
+			return client, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (d *fakeOAuthClientDAO) List() ([]*models.OAuthClient, error) {
+	return d.clients, nil
+}
+
+func (d *fakeOAuthClientDAO) Delete(id uint) (bool, error) {
+	for i, client := range d.clients {
+		if client.ID == id {
+			d.clients = slices.Delete(d.clients, i, i+1)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeOAuthCodeDAO 内存实现的 OAuthCodeDAO
+type fakeOAuthCodeDAO struct {
+	codes []*models.OAuthAuthorizationCode
+}
+
+func (d *fakeOAuthCodeDAO) Create(code *models.OAuthAuthorizationCode) error {
+	code.ID = uint(len(d.codes) + 1)
+	d.codes = append(d.codes, code)
+	return nil
+}
+
+func (d *fakeOAuthCodeDAO) GetByHash(hash string) (*models.OAuthAuthorizationCode, error) {
+	for _, code := range d.codes {
+		if code.CodeHash == hash {
+			return code, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (d *fakeOAuthCodeDAO) MarkUsed(id uint, usedAt time.Time) (bool, error) {
+	code := d.codes[id-1]
+	if code.UsedAt != nil {
+		return false, nil
+	}
+	code.UsedAt = &usedAt
+	return true, nil
+}
+
+func (d *fakeOAuthCodeDAO) SetToken(id uint, tokenID string, expiresAt time.Time) error {
+	d.codes[id-1].TokenID = tokenID
+	d.codes[id-1].TokenExpiresAt = &expiresAt
+	return nil
+}
+
+// fakeActionTokenDAO 内存实现的 ActionTokenDAO
+type fakeActionTokenDAO struct {
+	tokens []*models.ActionToken
+}
+
+func (d *fakeActionTokenDAO) Create(token *models.ActionToken) error {
+	token.ID = uint(len(d.tokens) + 1)
+	d.tokens = append(d.tokens, token)
+	return nil
+}
+
+func (d *fakeActionTokenDAO) Consume(tokenID, purpose string, usedAt time.Time) (bool, error) {
+	for _, token := range d.tokens {
+		if token.TokenID == tokenID && token.Purpose == purpose && token.UsedAt == nil && usedAt.Before(token.ExpiresAt) {
+			token.UsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *fakeActionTokenDAO) InvalidateAll(userID uint, purpose string, usedAt time.Time) error {
+	for _, token := range d.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
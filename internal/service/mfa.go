@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/auth"
+	"evaframe/pkg/config"
+	"evaframe/pkg/helpers"
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/loginguard"
+	"evaframe/pkg/revocation"
+	"evaframe/pkg/totp"
+)
+
+const (
+	// defaultMFAIssuer 验证器应用中显示的默认签发者
+	defaultMFAIssuer = "EvaFrame"
+	// defaultMFAPendingTTL 两步登录临时令牌的默认有效期
+	defaultMFAPendingTTL = 5 * time.Minute
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// totpSkew 允许的时钟偏差（时间步）
+	totpSkew = 1
+)
+
+var (
+	ErrInvalidMFACode     = errors.New("invalid verification code")
+	ErrInvalidMFAToken    = errors.New("invalid or expired mfa token")
+	ErrMFANotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrMFASetupNotStarted = errors.New("two-factor authentication setup has not been started")
+)
+
+// RecoveryCodeDAO 恢复码数据访问接口
+type RecoveryCodeDAO interface {
+	// Replace 删除用户已有的恢复码并写入新的恢复码
+	Replace(userID uint, codes []models.RecoveryCode) error
+	// Use 仅当恢复码存在且未使用时将其标记为已使用，返回是否标记成功
+	Use(userID uint, codeHash string, usedAt time.Time) (bool, error)
+}
+
+// TOTPSetup 启用两步验证时返回给客户端的信息
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFAService struct {
+	config          *config.Config
+	logger          *logger.Logger
+	jwt             *jwt.JWT
+	tokens          *TokenService
+	guard           *loginguard.Guard
+	revoked         revocation.Store
+	userDAO         UserDAO
+	recoveryCodeDAO RecoveryCodeDAO
+}
+
+func NewMFAService(
+	config *config.Config,
+	logger *logger.Logger,
+	jwt *jwt.JWT,
+	tokens *TokenService,
+	guard *loginguard.Guard,
+	revoked revocation.Store,
+	userDAO UserDAO,
+	recoveryCodeDAO RecoveryCodeDAO,
+) *MFAService {
+	return &MFAService{
+		config:          config,
+		logger:          logger,
+		jwt:             jwt,
+		tokens:          tokens,
+		guard:           guard,
+		revoked:         revoked,
+		userDAO:         userDAO,
+		recoveryCodeDAO: recoveryCodeDAO,
+	}
+}
+
+// BeginLogin 密码验证通过后签发等待第二因素验证的临时令牌
+func (s *MFAService) BeginLogin(user *models.User) (string, error) {
+	ttl := s.config.MFA.PendingTTL
+	if ttl == 0 {
+		ttl = defaultMFAPendingTTL
+	}
+	_, token, err := s.jwt.GeneratePurposeToken(user.ID, user.Email, jwt.PurposeMFAPending, ttl)
+	return token, err
+}
+
+// CompleteLogin 校验临时令牌和验证码（TOTP 或恢复码），通过后签发正式令牌或创建会话
+func (s *MFAService) CompleteLogin(mfaToken, code string) (*LoginResult, error) {
+	claims, err := s.jwt.ParsePurposeToken(mfaToken, jwt.PurposeMFAPending)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if isRevoked, err := s.revoked.IsRevoked(claims.ID); err != nil || isRevoked {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userDAO.GetByID(claims.UserID)
+	if err != nil || !user.TOTPEnabled {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := s.checkSecondFactor(user, code); err != nil {
+		// 锁定后临时令牌作废，需要重新输入密码
+		var locked *LoginLockedError
+		if errors.As(err, &locked) {
+			if err := s.revoked.Revoke(claims.ID, claims.ExpiresAt.Time); err != nil {
+				s.logger.LogIf(err)
+			}
+		}
+		return nil, err
+	}
+
+	// 临时令牌只能使用一次
+	if err := s.revoked.Revoke(claims.ID, claims.ExpiresAt.Time); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	result, err := s.tokens.IssueLogin(user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoString("mfa", "user completed two-factor login", user.Email)
+	return result, nil
+}
+
+// SetupTOTP 为当前用户生成新的 TOTP 密钥，需调用 ConfirmTOTP 确认后才会启用
+func (s *MFAService) SetupTOTP(ctx context.Context) (*TOTPSetup, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userDAO.UpdateTOTP(user.ID, secret, false); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	return &TOTPSetup{
+		Secret: secret,
+		URI:    totp.URI(s.issuer(), user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP 使用验证器应用生成的验证码确认启用两步验证，返回一次性展示的恢复码
+func (s *MFAService) ConfirmTOTP(ctx context.Context, code string) ([]string, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFASetupNotStarted
+	}
+
+	counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := s.userDAO.UpdateTOTP(user.ID, user.TOTPSecret, true); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+	if _, err := s.userDAO.AdvanceTOTPCounter(user.ID, counter); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	codes, err := s.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoString("mfa", "two-factor authentication enabled", user.Email)
+	return codes, nil
+}
+
+// DisableTOTP 关闭两步验证，需要提供有效的验证码或恢复码
+func (s *MFAService) DisableTOTP(ctx context.Context, code string) error {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+	if err := s.checkSecondFactor(user, code); err != nil {
+		return err
+	}
+
+	if err := s.userDAO.UpdateTOTP(user.ID, "", false); err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+	if err := s.recoveryCodeDAO.Replace(user.ID, nil); err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+
+	s.logger.InfoString("mfa", "two-factor authentication disabled", user.Email)
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	user, err := s.currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+	if err := s.checkSecondFactor(user, code); err != nil {
+		return nil, err
+	}
+	return s.generateRecoveryCodes(user.ID)
+}
+
+func (s *MFAService) currentUser(ctx context.Context) (*models.User, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	// 两步验证的设置只能在交互式登录后修改
+	if !principal.Interactive() {
+		return nil, ErrInteractiveLoginRequired
+	}
+	return s.userDAO.GetByID(principal.UserID)
+}
+
+func (s *MFAService) issuer() string {
+	if s.config.MFA.Issuer != "" {
+		return s.config.MFA.Issuer
+	}
+	return defaultMFAIssuer
+}
+
+// checkSecondFactor 校验第二因素并按用户统计连续失败次数，达到 lockout.max_attempts 后
+// 锁定，避免用同一个临时令牌或已登录的会话穷举 6 位验证码
+func (s *MFAService) checkSecondFactor(user *models.User, code string) error {
+	key := mfaGuardKey(user.ID)
+	if retryAfter := s.guard.Check(key, ""); retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	err := s.verifySecondFactor(user, code)
+	if errors.Is(err, ErrInvalidMFACode) {
+		for _, lockout := range s.guard.Fail(key, "") {
+			s.logger.WarnString("mfa", "two-factor locked", fmt.Sprintf("%s locked for %s after %d failed attempts",
+				user.Email, lockout.Duration, lockout.Failures))
+			return &LoginLockedError{RetryAfter: lockout.Duration}
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	s.guard.Succeed(key)
+	return nil
+}
+
+// mfaGuardKey 第二因素的失败计数与密码登录分开，按用户 ID 统计
+func mfaGuardKey(userID uint) string {
+	return "mfa:" + strconv.FormatUint(uint64(userID), 10)
+}
+
+// verifySecondFactor 校验 6 位 TOTP 验证码或恢复码，两者都只能使用一次
+func (s *MFAService) verifySecondFactor(user *models.User, code string) error {
+	code = normalizeCode(code)
+
+	if isTOTPCode(code) {
+		counter, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		advanced, err := s.userDAO.AdvanceTOTPCounter(user.ID, counter)
+		if err != nil {
+			s.logger.LogIf(err)
+			return err
+		}
+		if !advanced {
+			// 验证码已经使用过
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.recoveryCodeDAO.Use(user.ID, helpers.SHA256Hex(code), time.Now())
+	if err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	s.logger.InfoString("mfa", "recovery code used", user.Email)
+	return nil
+}
+
+// generateRecoveryCodes 生成并保存新的恢复码，返回明文（格式 xxxxx-xxxxx）
+func (s *MFAService) generateRecoveryCodes(userID uint) ([]string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		records = append(records, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: helpers.SHA256Hex(raw),
+		})
+	}
+
+	if err := s.recoveryCodeDAO.Replace(userID, records); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeCode 去掉空白和连字符并转为小写，方便用户输入
+func normalizeCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/auth"
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/loginguard"
+	"evaframe/pkg/revocation"
+	"evaframe/pkg/totp"
+)
+
+func newTestMFAService(t *testing.T) (*MFAService, *fakeUserDAO, revocation.Store) {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTestConfig()
+	cfg.Lockout.MaxAttempts = 3
+	users := newFakeUserDAO(&models.User{ID: 1, Email: "a@example.com", TOTPSecret: secret, TOTPEnabled: true})
+	store := revocation.NewMemoryStore()
+	s := NewMFAService(cfg, newTestLogger(), newTestJWT(t, cfg), nil, loginguard.NewGuard(cfg), store, users, &fakeRecoveryCodeDAO{})
+	return s, users, store
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Counter(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// wrongCode 返回当前时间窗口内都不会通过校验的验证码
+func wrongCode(t *testing.T, secret string) string {
+	t.Helper()
+	for _, code := range []string{"000000", "111111", "222222"} {
+		if _, ok := totp.Validate(secret, code, time.Now(), totpSkew); !ok {
+			return code
+		}
+	}
+	t.Fatal("no invalid code found")
+	return ""
+}
+
+// TestCompleteLoginLocksAfterFailures 连续输错验证码后锁定并作废临时令牌，
+// 之后即使验证码正确也不能完成登录
+func TestCompleteLoginLocksAfterFailures(t *testing.T) {
+	s, users, store := newTestMFAService(t)
+	user := users.users[1]
+
+	mfaToken, err := s.BeginLogin(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong := wrongCode(t, user.TOTPSecret)
+	for i := 0; i < 2; i++ {
+		if _, err := s.CompleteLogin(mfaToken, wrong); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidMFACode", i+1, err)
+		}
+	}
+
+	var locked *LoginLockedError
+	if _, err := s.CompleteLogin(mfaToken, wrong); !errors.As(err, &locked) || locked.RetryAfter <= 0 {
+		t.Fatalf("third failure: err = %v, want *LoginLockedError", err)
+	}
+	claims, err := s.jwt.ParsePurposeToken(mfaToken, jwt.PurposeMFAPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := store.IsRevoked(claims.ID); !revoked {
+		t.Fatal("mfa token was not revoked after the lockout")
+	}
+
+	// 重新通过密码验证拿到的临时令牌在锁定期内同样被拒绝
+	mfaToken, err = s.BeginLogin(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CompleteLogin(mfaToken, currentCode(t, user.TOTPSecret)); !errors.As(err, &locked) {
+		t.Fatalf("valid code during lockout: err = %v, want *LoginLockedError", err)
+	}
+}
+
+// TestManageTOTPSharesMFALockout 关闭两步验证和重新生成恢复码与两步登录共用失败计数
+func TestManageTOTPSharesMFALockout(t *testing.T) {
+	s, users, _ := newTestMFAService(t)
+	user := users.users[1]
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: user.ID, Email: user.Email})
+
+	wrong := wrongCode(t, user.TOTPSecret)
+	if _, err := s.RegenerateRecoveryCodes(ctx, wrong); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("err = %v, want ErrInvalidMFACode", err)
+	}
+	if err := s.DisableTOTP(ctx, "abcde-fghij"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("err = %v, want ErrInvalidMFACode", err)
+	}
+
+	var locked *LoginLockedError
+	if err := s.DisableTOTP(ctx, wrong); !errors.As(err, &locked) {
+		t.Fatalf("err = %v, want *LoginLockedError", err)
+	}
+	if err := s.DisableTOTP(ctx, currentCode(t, user.TOTPSecret)); !errors.As(err, &locked) {
+		t.Fatalf("valid code during lockout: err = %v, want *LoginLockedError", err)
+	}
+	if _, err := s.RegenerateRecoveryCodes(ctx, currentCode(t, user.TOTPSecret)); !errors.As(err, &locked) {
+		t.Fatalf("valid code during lockout: err = %v, want *LoginLockedError", err)
+	}
+	if !user.TOTPEnabled {
+		t.Fatal("two-factor authentication was disabled during the lockout")
+	}
+}
+
+// TestSecondFactorSuccessResetsFailures 验证成功后清零失败计数
+func TestSecondFactorSuccessResetsFailures(t *testing.T) {
+	s, users, _ := newTestMFAService(t)
+	user := users.users[1]
+	ctx := auth.NewContext(context.Background(), &auth.Principal{UserID: user.ID, Email: user.Email})
+
+	wrong := wrongCode(t, user.TOTPSecret)
+	for i := 0; i < 2; i++ {
+		if _, err := s.RegenerateRecoveryCodes(ctx, wrong); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("err = %v, want ErrInvalidMFACode", err)
+		}
+	}
+	if _, err := s.RegenerateRecoveryCodes(ctx, currentCode(t, user.TOTPSecret)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RegenerateRecoveryCodes(ctx, wrong); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("err = %v, want ErrInvalidMFACode after the counter was reset", err)
+	}
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/auth"
+	ejwt "evaframe/pkg/jwt"
+	"evaframe/pkg/oidc"
+	"evaframe/pkg/revocation"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testRedirectURI = "https://app.example.com/callback"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r0wW1gFWFOEjXk"
+)
+
+type oauthTest struct {
+	s       *OAuthService
+	users   *fakeUserDAO
+	revoked revocation.Store
+	ctx     context.Context // 用户 1 交互式登录
+}
+
+func newOAuthTest(t *testing.T) *oauthTest {
+	t.Helper()
+	cfg := newTestConfig()
+	role := models.Role{ID: 1, Name: "staff", Permissions: []models.Permission{{Name: "users:list"}, {Name: "users:delete"}}}
+	users := newFakeUserDAO(&models.User{ID: 1, Email: "a@example.com", Roles: []models.Role{role}})
+	store := revocation.NewMemoryStore()
+	return &oauthTest{
+		s:       NewOAuthService(newTestLogger(), newTestJWT(t, cfg), store, users, &fakeOAuthClientDAO{}, &fakeOAuthCodeDAO{}),
+		users:   users,
+		revoked: store,
+		ctx:     auth.NewContext(context.Background(), &auth.Principal{UserID: 1, Email: "a@example.com"}),
+	}
+}
+
+func (o *oauthTest) register(t *testing.T, public bool, grants ...string) *RegisteredClient {
+	t.Helper()
+	client, err := o.s.RegisterClient(o.ctx, &ClientRegistration{
+		Name:         "app",
+		Public:       public,
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   grants,
+		Scopes:       []string{"users:list", "users:delete"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// authorize 以用户 1 的身份授权，返回回调地址中的参数
+func (o *oauthTest) authorize(t *testing.T, req *AuthorizeRequest) url.Values {
+	t.Helper()
+	redirect, err := o.s.Authorize(o.ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(redirect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query()
+}
+
+func (o *oauthTest) code(t *testing.T, client *RegisteredClient) string {
+	t.Helper()
+	q := o.authorize(t, &AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		RedirectURI:         testRedirectURI,
+		State:               "xyz",
+		CodeChallenge:       oidc.S256Challenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	})
+	if q.Get("code") == "" || q.Get("state") != "xyz" {
+		t.Fatalf("callback = %v, want a code and the state", q)
+	}
+	return q.Get("code")
+}
+
+func (o *oauthTest) exchange(client *RegisteredClient, code, verifier string) (*OAuthToken, error) {
+	return o.s.Token(&TokenRequest{
+		GrantType:    GrantAuthorizationCode,
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: verifier,
+	})
+}
+
+func assertOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oauthErr *OAuthError
+	if !errors.As(err, &oauthErr) || oauthErr.Code != code {
+		t.Fatalf("err = %v, want OAuth error %s", err, code)
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, true)
+
+	token, err := o.exchange(client, o.code(t, client), testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := o.s.jwt.ParseToken(token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != 1 || claims.ClientID != client.ClientID || len(claims.Roles) != 0 ||
+		!slices.Equal(claims.Permissions, []string{"users:list", "users:delete"}) {
+		t.Fatalf("claims = %+v", claims)
+	}
+}
+
+func TestPKCEMismatchIsRejected(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, true)
+
+	_, err := o.exchange(client, o.code(t, client), "wrong-verifier-wrong-verifier-wrong-verifier")
+	assertOAuthError(t, err, OAuthInvalidGrant)
+}
+
+func TestPublicClientRequiresPKCE(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, true)
+
+	q := o.authorize(t, &AuthorizeRequest{ResponseType: "code", ClientID: client.ClientID, State: "xyz"})
+	if q.Get("error") != OAuthInvalidRequest || q.Get("code") != "" || q.Get("state") != "xyz" {
+		t.Fatalf("callback = %v, want invalid_request without a code", q)
+	}
+
+	q = o.authorize(t, &AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		CodeChallenge:       testVerifier,
+		CodeChallengeMethod: "plain",
+	})
+	if q.Get("error") != OAuthInvalidRequest {
+		t.Fatalf("callback = %v, want invalid_request for the plain method", q)
+	}
+}
+
+// TestCodeReuseRevokesToken 授权码被重复使用说明可能已泄露，吊销第一次换取的令牌
+func TestCodeReuseRevokesToken(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, false)
+	code := o.code(t, client)
+
+	token, err := o.exchange(client, code, testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = o.exchange(client, code, testVerifier)
+	assertOAuthError(t, err, OAuthInvalidGrant)
+
+	result, err := o.s.Introspect(client.ClientID, client.ClientSecret, token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Active {
+		t.Fatal("token issued for a reused code is still active")
+	}
+}
+
+func TestRedirectURIMismatch(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, false)
+
+	// 未注册的回调地址不能跳转，直接返回错误
+	_, err := o.s.Authorize(o.ctx, &AuthorizeRequest{
+		ResponseType: "code",
+		ClientID:     client.ClientID,
+		RedirectURI:  "https://evil.example.com/callback",
+	})
+	assertOAuthError(t, err, OAuthInvalidRequest)
+
+	// 兑换时的 redirect_uri 必须与授权时一致
+	_, err = o.s.Token(&TokenRequest{
+		GrantType:    GrantAuthorizationCode,
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		Code:         o.code(t, client),
+		RedirectURI:  testRedirectURI + "/other",
+		CodeVerifier: testVerifier,
+	})
+	assertOAuthError(t, err, OAuthInvalidGrant)
+
+	// 授权时携带了 redirect_uri，兑换时不能省略
+	_, err = o.s.Token(&TokenRequest{
+		GrantType:    GrantAuthorizationCode,
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		Code:         o.code(t, client),
+		CodeVerifier: testVerifier,
+	})
+	assertOAuthError(t, err, OAuthInvalidGrant)
+}
+
+// TestRedirectURIOmitted 授权时省略 redirect_uri 使用唯一注册的地址，兑换时同样可以省略
+func TestRedirectURIOmitted(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, false)
+
+	q := o.authorize(t, &AuthorizeRequest{ResponseType: "code", ClientID: client.ClientID})
+	_, err := o.s.Token(&TokenRequest{
+		GrantType:    GrantAuthorizationCode,
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+		Code:         q.Get("code"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCodeVerifierWithoutChallenge 授权时未使用 PKCE，兑换时提交 code_verifier 视为降级攻击
+func TestCodeVerifierWithoutChallenge(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, false)
+
+	q := o.authorize(t, &AuthorizeRequest{ResponseType: "code", ClientID: client.ClientID, RedirectURI: testRedirectURI})
+	_, err := o.exchange(client, q.Get("code"), testVerifier)
+	assertOAuthError(t, err, OAuthInvalidGrant)
+}
+
+func TestClientAuthenticationFailure(t *testing.T) {
+	o := newOAuthTest(t)
+	confidential := o.register(t, false, GrantClientCredentials)
+	public := o.register(t, true)
+
+	tests := []struct {
+		name             string
+		clientID, secret string
+	}{
+		{"unknown client", "nope", "secret"},
+		{"missing client id", "", ""},
+		{"wrong secret", confidential.ClientID, "wrong"},
+		{"missing secret", confidential.ClientID, ""},
+		{"public client with secret", public.ClientID, "secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := o.s.Token(&TokenRequest{GrantType: GrantClientCredentials, ClientID: tt.clientID, ClientSecret: tt.secret})
+			assertOAuthError(t, err, OAuthInvalidClient)
+			_, err = o.s.Introspect(tt.clientID, tt.secret, "token")
+			assertOAuthError(t, err, OAuthInvalidClient)
+		})
+	}
+}
+
+func TestIntrospectInactiveTokens(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, false, GrantClientCredentials)
+
+	token, err := o.s.Token(&TokenRequest{GrantType: GrantClientCredentials, ClientID: client.ClientID, ClientSecret: client.ClientSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := o.s.Introspect(client.ClientID, client.ClientSecret, token.AccessToken)
+	if err != nil || !result.Active || result.ClientID != client.ClientID {
+		t.Fatalf("result = %+v, %v, want an active token", result, err)
+	}
+
+	// 已过期
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &ejwt.Claims{
+		ClientID: client.ClientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "expired",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 已吊销
+	if err := o.s.Revoke(client.ClientID, client.ClientSecret, token.AccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tok := range map[string]string{"revoked": token.AccessToken, "expired": expired, "malformed": "not-a-token"} {
+		result, err := o.s.Introspect(client.ClientID, client.ClientSecret, tok)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Active || result.ClientID != "" {
+			t.Fatalf("%s token: result = %+v, want inactive without details", name, result)
+		}
+	}
+}
+
+func TestRevokeOtherClientsToken(t *testing.T) {
+	o := newOAuthTest(t)
+	owner := o.register(t, false, GrantClientCredentials)
+	other := o.register(t, false, GrantClientCredentials)
+
+	token, err := o.s.Token(&TokenRequest{GrantType: GrantClientCredentials, ClientID: owner.ClientID, ClientSecret: owner.ClientSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = o.s.Revoke(other.ClientID, other.ClientSecret, token.AccessToken)
+	assertOAuthError(t, err, OAuthUnauthorizedClient)
+
+	result, err := o.s.Introspect(owner.ClientID, owner.ClientSecret, token.AccessToken)
+	if err != nil || !result.Active {
+		t.Fatalf("result = %+v, %v, want the token to stay active", result, err)
+	}
+}
+
+// TestClientCredentialsFollowOwnerPermissions 注册者被降权或删除后，客户端凭据令牌随之失去权限
+func TestClientCredentialsFollowOwnerPermissions(t *testing.T) {
+	o := newOAuthTest(t)
+	client := o.register(t, false, GrantClientCredentials)
+	request := &TokenRequest{GrantType: GrantClientCredentials, ClientID: client.ClientID, ClientSecret: client.ClientSecret}
+
+	o.users.users[1].Roles = []models.Role{{Name: "user", Permissions: []models.Permission{{Name: "users:list"}}}}
+	token, err := o.s.Token(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := o.s.jwt.ParseToken(token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(claims.Permissions, []string{"users:list"}) {
+		t.Fatalf("permissions = %v, want only the owner's remaining permission", claims.Permissions)
+	}
+
+	delete(o.users.users, 1)
+	_, err = o.s.Token(request)
+	assertOAuthError(t, err, OAuthUnauthorizedClient)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/hasher"
+	"evaframe/pkg/helpers"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/oidc"
+)
+
+// oidcLoginTTL 跳转到提供方后完成登录的时限
+const oidcLoginTTL = 10 * time.Minute
+
+var (
+	ErrOIDCInvalidState     = errors.New("invalid or expired oidc login state")
+	ErrOIDCEmailNotVerified = errors.New("the provider did not return a verified email")
+	// ErrOIDCAccountConflict 同邮箱的本地账号尚未验证邮箱，自动关联可能让预先注册该邮箱的人接管账号
+	ErrOIDCAccountConflict = errors.New("an unverified account with this email already exists, sign in with your password and verify the email first")
+)
+
+// UserIdentityDAO 外部身份数据访问接口
+type UserIdentityDAO interface {
+	Create(identity *models.UserIdentity) error
+	GetByProviderSubject(provider, subject string) (*models.UserIdentity, error)
+}
+
+// OIDCLogin 跳转到提供方所需的信息
+type OIDCLogin struct {
+	AuthURL string
+	State   string
+}
+
+// OIDCService 通过外部 OIDC 提供方登录
+type OIDCService struct {
+	logger      *logger.Logger
+	hasher      hasher.PasswordHasher
+	providers   *oidc.Registry
+	states      *oidc.StateStore
+	users       *UserService
+	userDAO     UserDAO
+	identityDAO UserIdentityDAO
+}
+
+func NewOIDCService(
+	logger *logger.Logger,
+	hasher hasher.PasswordHasher,
+	providers *oidc.Registry,
+	states *oidc.StateStore,
+	users *UserService,
+	userDAO UserDAO,
+	identityDAO UserIdentityDAO,
+) *OIDCService {
+	return &OIDCService{
+		logger:      logger,
+		hasher:      hasher,
+		providers:   providers,
+		states:      states,
+		users:       users,
+		userDAO:     userDAO,
+		identityDAO: identityDAO,
+	}
+}
+
+// BeginLogin 生成 state、nonce 和 PKCE 参数，返回提供方登录页地址
+func (s *OIDCService) BeginLogin(ctx context.Context, providerName, loginHint string) (*OIDCLogin, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := helpers.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := helpers.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier), loginHint)
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	s.states.Save(state, &oidc.PendingLogin{
+		Provider:  providerName,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: time.Now().Add(oidcLoginTTL),
+	})
+	return &OIDCLogin{AuthURL: authURL, State: state}, nil
+}
+
+// CompleteLogin 用回调中的授权码换取并校验 ID Token，找到或创建对应的本地用户后登录
+func (s *OIDCService) CompleteLogin(ctx context.Context, providerName, state, code string) (*LoginResult, error) {
+	pending, ok := s.states.Take(state)
+	if !ok || pending.Provider != providerName {
+		return nil, ErrOIDCInvalidState
+	}
+
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := provider.Exchange(ctx, code, pending.Verifier, pending.Nonce)
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	user, err := s.resolveUser(providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoString("oidc", "user signed in with "+providerName, user.Email)
+	return s.users.login(user)
+}
+
+// resolveUser 依次按外部身份、已验证邮箱查找本地用户，都不存在时创建新用户
+func (s *OIDCService) resolveUser(provider string, claims *oidc.IDTokenClaims) (*models.User, error) {
+	if identity, err := s.identityDAO.GetByProviderSubject(provider, claims.Subject); err == nil {
+		return s.userDAO.GetByID(identity.UserID)
+	}
+
+	// 只有提供方确认过的邮箱才能用于关联或注册
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err := s.userDAO.GetByEmail(claims.Email)
+	if err == nil {
+		if user.EmailVerifiedAt == nil {
+			return nil, ErrOIDCAccountConflict
+		}
+	} else {
+		if user, err = s.createUser(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.identityDAO.Create(&models.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	s.logger.InfoString("oidc", "identity linked to "+provider, user.Email)
+	return user, nil
+}
+
+// createUser 为外部身份创建本地用户，密码为随机值，用户可通过找回密码设置
+func (s *OIDCService) createUser(claims *oidc.IDTokenClaims) (*models.User, error) {
+	if exists, err := s.userDAO.ExistsByEmail(claims.Email); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	} else if exists {
+		// 邮箱属于已删除的用户
+		return nil, ErrEmailTaken
+	}
+
+	password, err := helpers.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Name:            name,
+		Email:           claims.Email,
+		Password:        hashedPassword,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.userDAO.Create(user); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	s.logger.InfoString("oidc", "user registered via oidc", user.Email)
+	return user, nil
+}
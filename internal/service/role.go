@@ -0,0 +1,8 @@
+package service
+
+import "evaframe/internal/models"
+
+// RoleDAO 角色数据访问接口
+type RoleDAO interface {
+	GetByNames(names []string) ([]models.Role, error)
+}
@@ -1,5 +1,18 @@
 package service
 
-import "github.com/google/wire"
+import (
+	"evaframe/pkg/auth"
 
-var ProviderSet = wire.NewSet(NewUserService)
+	"github.com/google/wire"
+)
+
+var ProviderSet = wire.NewSet(
+	NewUserService,
+	NewTokenService,
+	NewMFAService,
+	NewAccountService,
+	NewAPIKeyService,
+	NewOIDCService,
+	NewOAuthService,
+	wire.Bind(new(auth.APIKeyResolver), new(*APIKeyService)),
+)
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/config"
+	"evaframe/pkg/helpers"
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/session"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidRefreshToken 刷新令牌不存在、已过期或已被吊销
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused 已使用过的刷新令牌被再次提交
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// RefreshTokenDAO 刷新令牌数据访问接口
+type RefreshTokenDAO interface {
+	Create(token *models.RefreshToken) error
+	GetByHash(hash string) (*models.RefreshToken, error)
+	// MarkUsed 仅当令牌尚未被使用时将其标记为已使用，返回是否标记成功
+	MarkUsed(id uint, usedAt time.Time) (bool, error)
+	RevokeFamily(familyID string, revokedAt time.Time) error
+	RevokeByUser(userID uint, revokedAt time.Time) error
+}
+
+// TokenPair 访问令牌 + 刷新令牌
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type TokenService struct {
+	config          *config.Config
+	logger          *logger.Logger
+	jwt             *jwt.JWT
+	sessions        *session.Manager
+	userDAO         UserDAO
+	refreshTokenDAO RefreshTokenDAO
+}
+
+func NewTokenService(
+	config *config.Config,
+	logger *logger.Logger,
+	jwt *jwt.JWT,
+	sessions *session.Manager,
+	userDAO UserDAO,
+	refreshTokenDAO RefreshTokenDAO,
+) *TokenService {
+	return &TokenService{
+		config:          config,
+		logger:          logger,
+		jwt:             jwt,
+		sessions:        sessions,
+		userDAO:         userDAO,
+		refreshTokenDAO: refreshTokenDAO,
+	}
+}
+
+// IssueLogin 用户通过全部验证后签发登录凭据：JWT 模式为令牌对，会话模式为新的服务端会话
+func (s *TokenService) IssueLogin(user *models.User) (*LoginResult, error) {
+	if !s.sessions.Enabled() {
+		tokens, err := s.IssueTokenPair(user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, Tokens: tokens}, nil
+	}
+
+	sess, token, err := s.sessions.Create(session.Identity{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Roles:       user.RoleNames(),
+		Permissions: user.PermissionNames(),
+	})
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+	return &LoginResult{User: user, Session: sess, SessionToken: token}, nil
+}
+
+// EndSession 删除服务端会话
+func (s *TokenService) EndSession(id string) error {
+	if err := s.sessions.Destroy(id); err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+	return nil
+}
+
+// IssueTokenPair 为用户签发一组新令牌，刷新令牌开启一个新的令牌家族
+func (s *TokenService) IssueTokenPair(user *models.User) (*TokenPair, error) {
+	familyID, err := helpers.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(user, familyID)
+}
+
+// Refresh 使用刷新令牌换取新令牌，旧刷新令牌随即失效
+func (s *TokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	record, err := s.refreshTokenDAO.GetByHash(helpers.SHA256Hex(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil || now.After(record.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 令牌已被使用过，说明可能已泄露，吊销整个家族
+	if record.UsedAt != nil {
+		return nil, s.revokeReusedFamily(record, now)
+	}
+
+	// 条件更新防止并发请求同时使用同一个令牌
+	marked, err := s.refreshTokenDAO.MarkUsed(record.ID, now)
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+	if !marked {
+		return nil, s.revokeReusedFamily(record, now)
+	}
+
+	user, err := s.userDAO.GetByID(record.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	return s.issue(user, record.FamilyID)
+}
+
+// RevokeAllLogins 吊销用户所有的刷新令牌和服务端会话（keepSession 除外），
+// 已签发的访问令牌在过期前仍然有效
+func (s *TokenService) RevokeAllLogins(userID uint, keepSession string) error {
+	if err := s.refreshTokenDAO.RevokeByUser(userID, time.Now()); err != nil {
+		return err
+	}
+	return s.sessions.DestroyUser(userID, keepSession)
+}
+
+// RevokeRefreshToken 吊销刷新令牌所在的整个令牌家族，仅允许令牌所属用户操作
+func (s *TokenService) RevokeRefreshToken(userID uint, refreshToken string) error {
+	record, err := s.refreshTokenDAO.GetByHash(helpers.SHA256Hex(refreshToken))
+	if err != nil || record.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+	return s.refreshTokenDAO.RevokeFamily(record.FamilyID, time.Now())
+}
+
+func (s *TokenService) issue(user *models.User, familyID string) (*TokenPair, error) {
+	accessToken, err := s.jwt.GenerateToken(jwt.Identity{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Roles:       user.RoleNames(),
+		Permissions: user.PermissionNames(),
+	})
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	refreshToken, err := helpers.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: helpers.SHA256Hex(refreshToken),
+		ExpiresAt: time.Now().Add(s.jwt.RefreshTTL()),
+	}
+	if err := s.refreshTokenDAO.Create(record); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.jwt.AccessTTL().Seconds()),
+	}, nil
+}
+
+func (s *TokenService) revokeReusedFamily(record *models.RefreshToken, now time.Time) error {
+	s.logger.Warn("token",
+		zap.String("event", "refresh token reuse detected"),
+		zap.Uint("user_id", record.UserID),
+		zap.String("family_id", record.FamilyID),
+	)
+	if err := s.refreshTokenDAO.RevokeFamily(record.FamilyID, now); err != nil {
+		s.logger.LogIf(err)
+	}
+	return ErrRefreshTokenReused
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/helpers"
+	"evaframe/pkg/session"
+)
+
+type tokenTest struct {
+	s       *TokenService
+	refresh *fakeRefreshTokenDAO
+}
+
+func newTokenTest(t *testing.T) *tokenTest {
+	t.Helper()
+	cfg := newTestConfig()
+	sessions, err := session.NewManager(cfg, session.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresh := &fakeRefreshTokenDAO{}
+	users := newFakeUserDAO(&models.User{ID: 1, Email: "a@example.com"})
+	return &tokenTest{
+		s:       NewTokenService(cfg, newTestLogger(), newTestJWT(t, cfg), sessions, users, refresh),
+		refresh: refresh,
+	}
+}
+
+func (tt *tokenTest) login(t *testing.T) *TokenPair {
+	t.Helper()
+	pair, err := tt.s.IssueTokenPair(&models.User{ID: 1, Email: "a@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair
+}
+
+func (tt *tokenTest) record(t *testing.T, refreshToken string) *models.RefreshToken {
+	t.Helper()
+	record, err := tt.refresh.GetByHash(helpers.SHA256Hex(refreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+// assertFamilyRevoked 令牌家族中的所有令牌都已被吊销
+func (tt *tokenTest) assertFamilyRevoked(t *testing.T, familyID string) {
+	t.Helper()
+	for _, token := range tt.refresh.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			t.Fatalf("refresh token %d in family %s not revoked", token.ID, familyID)
+		}
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	tt := newTokenTest(t)
+	old := tt.login(t)
+
+	pair, err := tt.s.Refresh(old.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.AccessToken == "" || pair.RefreshToken == "" || pair.RefreshToken == old.RefreshToken {
+		t.Fatalf("pair = %+v, want a new access and refresh token", pair)
+	}
+
+	previous, next := tt.record(t, old.RefreshToken), tt.record(t, pair.RefreshToken)
+	if previous.UsedAt == nil {
+		t.Fatal("old refresh token not marked as used")
+	}
+	if next.FamilyID != previous.FamilyID || next.UsedAt != nil || next.RevokedAt != nil {
+		t.Fatalf("new refresh token = %+v, want an unused token in the same family", next)
+	}
+	if _, err := tt.s.Refresh(pair.RefreshToken); err != nil {
+		t.Fatalf("refresh with the rotated token: %v", err)
+	}
+}
+
+// TestRefreshReuseRevokesFamily 重放已使用的刷新令牌会吊销整个家族，包括轮换出的新令牌
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	tt := newTokenTest(t)
+	old := tt.login(t)
+	pair, err := tt.s.Refresh(old.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tt.s.Refresh(old.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("err = %v, want ErrRefreshTokenReused", err)
+	}
+	tt.assertFamilyRevoked(t, tt.record(t, old.RefreshToken).FamilyID)
+	if _, err := tt.s.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh with the rotated token: err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshRejectsInvalidToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		spoil func(record *models.RefreshToken)
+	}{
+		{"expired", func(record *models.RefreshToken) { record.ExpiresAt = time.Now().Add(-time.Second) }},
+		{"revoked", func(record *models.RefreshToken) { now := time.Now(); record.RevokedAt = &now }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tt := newTokenTest(t)
+			pair := tt.login(t)
+			test.spoil(tt.record(t, pair.RefreshToken))
+
+			if _, err := tt.s.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Fatalf("err = %v, want ErrInvalidRefreshToken", err)
+			}
+			if tt.record(t, pair.RefreshToken).UsedAt != nil {
+				t.Fatal("rejected refresh token marked as used")
+			}
+		})
+	}
+
+	tt := newTokenTest(t)
+	if _, err := tt.s.Refresh("unknown"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("unknown token: err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+// racingRefreshTokenDAO 模拟并发请求在读取之后、标记之前抢先使用了同一个令牌
+type racingRefreshTokenDAO struct {
+	*fakeRefreshTokenDAO
+}
+
+func (d racingRefreshTokenDAO) MarkUsed(id uint, usedAt time.Time) (bool, error) {
+	d.fakeRefreshTokenDAO.MarkUsed(id, usedAt)
+	return d.fakeRefreshTokenDAO.MarkUsed(id, usedAt)
+}
+
+func TestRefreshLosingMarkUsedRaceRevokesFamily(t *testing.T) {
+	tt := newTokenTest(t)
+	tt.s.refreshTokenDAO = racingRefreshTokenDAO{tt.refresh}
+	pair := tt.login(t)
+
+	if _, err := tt.s.Refresh(pair.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("err = %v, want ErrRefreshTokenReused", err)
+	}
+	tt.assertFamilyRevoked(t, tt.record(t, pair.RefreshToken).FamilyID)
+	if len(tt.refresh.tokens) != 1 {
+		t.Fatalf("issued %d refresh tokens, want no new token", len(tt.refresh.tokens)-1)
+	}
+}
+
+// failingRefreshTokenDAO 模拟数据库故障
+type failingRefreshTokenDAO struct {
+	*fakeRefreshTokenDAO
+}
+
+var errDatabaseDown = errors.New("database is down")
+
+func (d failingRefreshTokenDAO) GetByHash(hash string) (*models.RefreshToken, error) {
+	return nil, errDatabaseDown
+}
+
+// TestRefreshPassesThroughStorageErrors 存储故障不能当作令牌无效处理，否则会被响应为 401
+func TestRefreshPassesThroughStorageErrors(t *testing.T) {
+	tt := newTokenTest(t)
+	tt.s.refreshTokenDAO = failingRefreshTokenDAO{tt.refresh}
+
+	_, err := tt.s.Refresh("token")
+	if !errors.Is(err, errDatabaseDown) {
+		t.Fatalf("err = %v, want the storage error", err)
+	}
+}
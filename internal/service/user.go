@@ -1,53 +1,143 @@
 package service
 
 import (
-	"crypto/md5"
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
+	"time"
 
 	"evaframe/internal/models"
+	"evaframe/pkg/auth"
 	"evaframe/pkg/config"
+	"evaframe/pkg/hasher"
 	"evaframe/pkg/jwt"
 	"evaframe/pkg/logger"
+	"evaframe/pkg/loginguard"
+	"evaframe/pkg/revocation"
+	"evaframe/pkg/session"
 )
 
+var (
+	// ErrUnauthenticated 上下文中没有已认证身份
+	ErrUnauthenticated = errors.New("user not authenticated")
+	// ErrInteractiveLoginRequired 操作只能由用户本人登录后执行，不接受 API 密钥或 OAuth2 客户端的令牌
+	ErrInteractiveLoginRequired = errors.New("this operation requires an interactive login")
+	// ErrInvalidCredentials 邮箱不存在和密码错误返回同一个错误，避免泄露邮箱是否注册
+	ErrInvalidCredentials = errors.New("invalid email or password")
+
+	ErrUserNotFound      = errors.New("user not found")
+	ErrEmailTaken        = errors.New("email already exists")
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrSamePassword      = errors.New("new password must differ from the current password")
+	ErrCannotDeleteSelf  = errors.New("cannot delete your own account")
+)
+
+// LoginLockedError 连续登录或两步验证失败次数过多，账号或 IP 被临时锁定
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return "too many failed login attempts, try again later"
+}
+
+// LoginResult 登录结果，开启两步验证的用户只返回 MFAToken，需通过 /login/mfa 换取正式令牌
+//
+// JWT 模式下返回 Tokens；会话模式下返回 Session 和 SessionToken，由 Handler 写入 Cookie
+type LoginResult struct {
+	User         *models.User
+	Tokens       *TokenPair
+	Session      *session.Session
+	SessionToken string
+	MFARequired  bool
+	MFAToken     string
+}
+
 // UserDAO 接口定义 - Service 层定义需要的数据访问方法
 type UserDAO interface {
 	Create(user *models.User) error
 	GetByID(id uint) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
+	// ExistsByEmail 判断邮箱是否已被占用，包括已软删除的用户
+	ExistsByEmail(email string) (bool, error)
 	List(offset, limit int) ([]*models.User, error)
+	UpdateProfile(id uint, name, email string, emailVerifiedAt *time.Time) error
+	UpdatePassword(id uint, password string) error
+	// Delete 软删除用户，返回是否删除成功
+	Delete(id uint) (bool, error)
+	// Restore 恢复已软删除的用户，返回是否恢复成功
+	Restore(id uint) (bool, error)
+	ReplaceRoles(user *models.User, roles []models.Role) error
+	MarkEmailVerified(id uint, verifiedAt time.Time) error
+	UpdateTOTP(id uint, secret string, enabled bool) error
+	// AdvanceTOTPCounter 仅当 counter 大于已记录的时间步时更新，返回是否更新成功
+	AdvanceTOTPCounter(id uint, counter int64) (bool, error)
 }
 
 type UserService struct {
 	config  *config.Config
 	logger  *logger.Logger
 	jwt     *jwt.JWT
+	hasher  hasher.PasswordHasher
+	tokens  *TokenService
+	mfa     *MFAService
+	account *AccountService
+	guard   *loginguard.Guard
+	revoked revocation.Store
 	userDAO UserDAO
+	roleDAO RoleDAO
+
+	// dummyHash 用于邮箱不存在时执行一次同等开销的密码校验
+	dummyOnce sync.Once
+	dummyHash string
 }
 
 func NewUserService(
 	config *config.Config,
 	logger *logger.Logger,
 	jwt *jwt.JWT,
+	hasher hasher.PasswordHasher,
+	tokens *TokenService,
+	mfa *MFAService,
+	account *AccountService,
+	guard *loginguard.Guard,
+	revoked revocation.Store,
 	userDAO UserDAO,
+	roleDAO RoleDAO,
 ) *UserService {
 	return &UserService{
 		config:  config,
 		logger:  logger,
 		jwt:     jwt,
+		hasher:  hasher,
+		tokens:  tokens,
+		mfa:     mfa,
+		account: account,
+		guard:   guard,
+		revoked: revoked,
 		userDAO: userDAO,
+		roleDAO: roleDAO,
 	}
 }
 
 // 业务逻辑方法 - 直接使用领域对象
 func (s *UserService) CreateUser(name, email, password string) (*models.User, error) {
 	// 检查邮箱是否已存在
-	if _, err := s.userDAO.GetByEmail(email); err == nil {
-		return nil, fmt.Errorf("email already exists")
+	if exists, err := s.userDAO.ExistsByEmail(email); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	} else if exists {
+		return nil, ErrEmailTaken
 	}
 
-	// 密码加密（简单MD5，生产环境应使用bcrypt）
-	hashedPassword := fmt.Sprintf("%x", md5.Sum([]byte(password)))
+	// 密码加密
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
 
 	user := &models.User{
 		Name:     name,
@@ -60,32 +150,116 @@ func (s *UserService) CreateUser(name, email, password string) (*models.User, er
 		return nil, err
 	}
 
+	// 发送邮箱验证邮件，失败不影响注册，用户可以稍后重新发送
+	if err := s.account.SendVerificationEmail(context.Background(), user); err != nil {
+		s.logger.LogWarnIf(err)
+	}
+
 	s.logger.InfoString("user", "user registered successfully", email)
 	return user, nil
 }
 
-func (s *UserService) AuthenticateUser(email, password string) (*models.User, string, error) {
+func (s *UserService) AuthenticateUser(email, password, ip string) (*LoginResult, error) {
+	// 账号或 IP 处于锁定期内时直接拒绝，不再校验密码
+	if retryAfter := s.guard.Check(email, ip); retryAfter > 0 {
+		return nil, &LoginLockedError{RetryAfter: retryAfter}
+	}
+
 	// 查找用户
 	user, err := s.userDAO.GetByEmail(email)
 	if err != nil {
-		return nil, "", fmt.Errorf("user not found")
+		// 邮箱不存在时同样校验一次密码，避免通过响应时间判断邮箱是否注册
+		s.verifyDummyPassword(password)
+		s.loginFailed(email, ip)
+		return nil, ErrInvalidCredentials
 	}
 
 	// 验证密码
-	hashedPassword := fmt.Sprintf("%x", md5.Sum([]byte(password)))
-	if user.Password != hashedPassword {
-		return nil, "", fmt.Errorf("invalid credentials")
+	ok, err := s.hasher.Verify(user.Password, password)
+	if err != nil {
+		s.logger.LogIf(err)
+	}
+	if !ok {
+		s.loginFailed(email, ip)
+		return nil, ErrInvalidCredentials
+	}
+	s.guard.Succeed(email)
+
+	// 旧算法（如 MD5）或旧参数生成的哈希，登录成功后透明升级
+	s.rehashPassword(user, password)
+
+	return s.login(user)
+}
+
+// login 用户已通过第一因素（密码或外部身份）验证，签发令牌或进入两步验证
+func (s *UserService) login(user *models.User) (*LoginResult, error) {
+	// 开启两步验证的用户先签发临时令牌
+	if user.TOTPEnabled {
+		mfaToken, err := s.mfa.BeginLogin(user)
+		if err != nil {
+			s.logger.LogIf(err)
+			return nil, err
+		}
+		s.logger.InfoString("user", "user passed first factor, mfa required", user.Email)
+		return &LoginResult{User: user, MFARequired: true, MFAToken: mfaToken}, nil
 	}
 
-	// 生成JWT token
-	token, err := s.jwt.GenerateToken(user.ID, user.Email)
+	// 签发访问令牌和刷新令牌，或创建服务端会话
+	result, err := s.tokens.IssueLogin(user)
 	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoString("user", "user logged in successfully", user.Email)
+	return result, nil
+}
+
+// RefreshToken 使用刷新令牌换取新的令牌对
+func (s *UserService) RefreshToken(refreshToken string) (*TokenPair, error) {
+	return s.tokens.Refresh(refreshToken)
+}
+
+// Logout 吊销当前访问令牌，提供刷新令牌时一并吊销其所在的令牌家族；会话登录时删除当前会话
+func (s *UserService) Logout(ctx context.Context, refreshToken string) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	// API 密钥没有会话可退出，应通过吊销接口作废
+	if principal.IsAPIKey() {
+		return ErrInteractiveLoginRequired
+	}
+
+	if principal.IsSession() {
+		if err := s.tokens.EndSession(principal.SessionID); err != nil {
+			return err
+		}
+		s.logger.InfoString("user", "user logged out", principal.Email)
+		return nil
+	}
+
+	if err := s.revoked.Revoke(principal.TokenID, principal.ExpiresAt); err != nil {
 		s.logger.LogIf(err)
-		return nil, "", err
+		return err
+	}
+
+	if refreshToken != "" {
+		if err := s.tokens.RevokeRefreshToken(principal.UserID, refreshToken); err != nil {
+			return err
+		}
 	}
 
-	s.logger.InfoString("user", "user logged in successfully", email)
-	return user, token, nil
+	s.logger.InfoString("user", "user logged out", principal.Email)
+	return nil
+}
+
+// GetCurrentUser 返回当前请求的已认证用户
+func (s *UserService) GetCurrentUser(ctx context.Context) (*models.User, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return s.userDAO.GetByID(principal.UserID)
 }
 
 func (s *UserService) GetUserByID(id uint) (*models.User, error) {
@@ -95,3 +269,218 @@ func (s *UserService) GetUserByID(id uint) (*models.User, error) {
 func (s *UserService) ListUsers(offset, limit int) ([]*models.User, error) {
 	return s.userDAO.List(offset, limit)
 }
+
+// UpdateProfile 修改当前用户的资料，参数为 nil 表示不修改；修改邮箱后需要重新验证
+func (s *UserService) UpdateProfile(ctx context.Context, name, email *string) (*models.User, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	user, err := s.userDAO.GetByID(principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	emailChanged := email != nil && *email != user.Email
+	if emailChanged {
+		// 邮箱是登录凭据，不允许通过 API 密钥或 OAuth2 令牌修改
+		if !principal.Interactive() {
+			return nil, ErrInteractiveLoginRequired
+		}
+		if exists, err := s.userDAO.ExistsByEmail(*email); err != nil {
+			s.logger.LogIf(err)
+			return nil, err
+		} else if exists {
+			return nil, ErrEmailTaken
+		}
+		user.Email = *email
+		user.EmailVerifiedAt = nil
+	}
+	if name != nil {
+		user.Name = *name
+	}
+
+	if err := s.userDAO.UpdateProfile(user.ID, user.Name, user.Email, user.EmailVerifiedAt); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	if emailChanged {
+		if err := s.account.SendVerificationEmail(ctx, user); err != nil {
+			s.logger.LogWarnIf(err)
+		}
+	}
+
+	s.logger.InfoString("user", "profile updated", user.Email)
+	return user, nil
+}
+
+// ChangePassword 校验当前密码后修改密码，并吊销所有刷新令牌和其他会话让其他设备重新登录
+func (s *UserService) ChangePassword(ctx context.Context, currentPassword, newPassword string) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !principal.Interactive() {
+		return ErrInteractiveLoginRequired
+	}
+
+	user, err := s.userDAO.GetByID(principal.UserID)
+	if err != nil {
+		return err
+	}
+
+	// 当前密码与登录密码是同一个凭据，共用登录失败的计数和锁定
+	if retryAfter := s.guard.Check(user.Email, ""); retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	ok, err = s.hasher.Verify(user.Password, currentPassword)
+	if err != nil {
+		s.logger.LogIf(err)
+	}
+	if !ok {
+		s.loginFailed(user.Email, "")
+		return ErrIncorrectPassword
+	}
+	s.guard.Succeed(user.Email)
+
+	if currentPassword == newPassword {
+		return ErrSamePassword
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+	if err := s.userDAO.UpdatePassword(user.ID, hashedPassword); err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+
+	// 其他设备需要重新登录，当前会话保留
+	if err := s.tokens.RevokeAllLogins(user.ID, principal.SessionID); err != nil {
+		s.logger.LogIf(err)
+	}
+
+	s.logger.InfoString("user", "password changed", user.Email)
+	return nil
+}
+
+// DeleteUser 软删除用户并吊销其刷新令牌和会话，管理员不能删除自己
+func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if principal.UserID == id {
+		return ErrCannotDeleteSelf
+	}
+
+	deleted, err := s.userDAO.Delete(id)
+	if err != nil {
+		s.logger.LogIf(err)
+		return err
+	}
+	if !deleted {
+		return ErrUserNotFound
+	}
+
+	if err := s.tokens.RevokeAllLogins(id, ""); err != nil {
+		s.logger.LogIf(err)
+	}
+
+	s.logger.InfoJSON("user", "user deleted", map[string]any{"user_id": id, "by": principal.UserID})
+	return nil
+}
+
+// RestoreUser 恢复已软删除的用户
+func (s *UserService) RestoreUser(ctx context.Context, id uint) (*models.User, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	restored, err := s.userDAO.Restore(id)
+	if err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+	if !restored {
+		return nil, ErrUserNotFound
+	}
+
+	s.logger.InfoJSON("user", "user restored", map[string]any{"user_id": id, "by": principal.UserID})
+	return s.userDAO.GetByID(id)
+}
+
+// AssignRoles 将用户的角色替换为指定角色，并吊销该用户的刷新令牌和所有会话。
+// 会话在登录时记录角色和权限，不吊销的话被降权的用户在会话有效期内仍保留原有权限；
+// 已签发的访问令牌在过期前仍按旧角色生效
+func (s *UserService) AssignRoles(userID uint, roleNames []string) (*models.User, error) {
+	user, err := s.userDAO.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roleDAO.GetByNames(roleNames)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(slices.Compact(slices.Sorted(slices.Values(roleNames)))) {
+		return nil, fmt.Errorf("role not found")
+	}
+
+	if err := s.userDAO.ReplaceRoles(user, roles); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+	if err := s.tokens.RevokeAllLogins(userID, ""); err != nil {
+		s.logger.LogIf(err)
+		return nil, err
+	}
+
+	s.logger.InfoJSON("user", "roles assigned", map[string]any{"user_id": userID, "roles": roleNames})
+	return s.userDAO.GetByID(userID)
+}
+
+// loginFailed 记录一次登录失败，触发锁定时记录日志
+func (s *UserService) loginFailed(email, ip string) {
+	for _, lockout := range s.guard.Fail(email, ip) {
+		s.logger.WarnString("user", "login locked", fmt.Sprintf("%s %s locked for %s after %d failed attempts",
+			lockout.Scope, lockout.Key, lockout.Duration, lockout.Failures))
+	}
+}
+
+func (s *UserService) verifyDummyPassword(password string) {
+	s.dummyOnce.Do(func() {
+		hash, err := s.hasher.Hash("evaframe-dummy-password")
+		s.logger.LogIf(err)
+		s.dummyHash = hash
+	})
+	if s.dummyHash != "" {
+		_, _ = s.hasher.Verify(s.dummyHash, password)
+	}
+}
+
+// rehashPassword 在需要时用当前算法重新生成密码哈希，失败不影响登录
+func (s *UserService) rehashPassword(user *models.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		s.logger.LogWarnIf(err)
+		return
+	}
+
+	if err := s.userDAO.UpdatePassword(user.ID, hashedPassword); err != nil {
+		s.logger.LogWarnIf(err)
+		return
+	}
+
+	user.Password = hashedPassword
+	s.logger.InfoString("user", "password hash upgraded", user.Email)
+}
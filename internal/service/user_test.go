@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"evaframe/internal/models"
+	"evaframe/pkg/session"
+)
+
+// TestAssignRolesEndsSessions 会话在登录时记录权限，修改角色后必须删除，否则降权要等会话过期才生效
+func TestAssignRolesEndsSessions(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Auth.Mode = "session"
+	sessions, err := session.NewManager(cfg, session.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := models.Role{ID: 1, Name: "admin", Permissions: []models.Permission{{Name: "*"}}}
+	users := newFakeUserDAO(&models.User{ID: 1, Email: "a@example.com", Roles: []models.Role{admin}})
+	refreshTokens := &fakeRefreshTokenDAO{}
+	tokens := NewTokenService(cfg, newTestLogger(), newTestJWT(t, cfg), sessions, users, refreshTokens)
+	s := NewUserService(cfg, newTestLogger(), nil, nil, tokens, nil, nil, nil, nil, users,
+		&fakeRoleDAO{roles: []models.Role{admin, {ID: 2, Name: "user"}}})
+
+	login, err := tokens.IssueLogin(users.users[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Load(login.SessionToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.AssignRoles(1, []string{"user"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Load(login.SessionToken); !errors.Is(err, session.ErrNotFound) {
+		t.Fatalf("load after role change: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// APIKeyPrefix 个人 API 密钥的固定前缀，便于识别和密钥扫描工具匹配
+const APIKeyPrefix = "eva_"
+
+// ErrInvalidAPIKey API 密钥不存在、已过期或已吊销
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyResolver 将 API 密钥解析为其代表的 Principal，由认证中间件调用
+type APIKeyResolver interface {
+	// ResolveAPIKey 密钥无效时返回 ErrInvalidAPIKey
+	ResolveAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// IsAPIKey 判断凭据是否为 API 密钥
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
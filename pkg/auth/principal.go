@@ -0,0 +1,89 @@
+// Package auth 定义已认证身份（Principal）及其在请求上下文中的存取方式
+//
+// 认证中间件负责写入 Principal，Handler 与 Service 通过 FromContext 读取，
+// 两者之间只依赖本包，不再约定 gin 上下文中的字符串键。
+package auth
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionAll 通配权限，拥有该权限的主体通过所有权限检查
+const PermissionAll = "*"
+
+// ginKey Principal 在 gin 上下文中的键
+const ginKey = "auth.principal"
+
+// ctxKey Principal 在 context.Context 中的键
+type ctxKey struct{}
+
+// Principal 当前请求的已认证身份
+type Principal struct {
+	UserID      uint
+	Email       string
+	Roles       []string
+	Permissions []string
+	TokenID     string    // 访问令牌 ID（jti），用于吊销
+	ExpiresAt   time.Time // 访问令牌过期时间，不过期的 API 密钥为零值
+	APIKeyID    uint      // 使用 API 密钥认证时的密钥 ID
+	ClientID    string    // 通过 OAuth2 授权签发的令牌所属的客户端；客户端凭据模式下 UserID 为 0
+	SessionID   string    // 使用服务端会话认证时的会话 ID
+}
+
+// IsSession 判断是否通过服务端会话认证
+func (p *Principal) IsSession() bool {
+	return p.SessionID != ""
+}
+
+// IsAPIKey 判断是否通过 API 密钥认证
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != 0
+}
+
+// Interactive 判断是否为用户本人交互式登录，API 密钥和 OAuth2 客户端代表用户调用时返回 false
+func (p *Principal) Interactive() bool {
+	return p.APIKeyID == 0 && p.ClientID == ""
+}
+
+// HasPermission 判断是否拥有指定权限
+func (p *Principal) HasPermission(permission string) bool {
+	return slices.Contains(p.Permissions, PermissionAll) || slices.Contains(p.Permissions, permission)
+}
+
+// HasRole 判断是否拥有指定角色
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// NewContext 返回携带 Principal 的新 context
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// WithPrincipal 将 Principal 同时写入 gin 上下文和 c.Request 的 context，
+// 这样只拿到 context.Context 的 Service 也能读取当前身份
+func WithPrincipal(c *gin.Context, p *Principal) {
+	c.Set(ginKey, p)
+	c.Request = c.Request.WithContext(NewContext(c.Request.Context(), p))
+}
+
+// FromContext 读取当前请求的 Principal，参数可以是 *gin.Context 或普通 context.Context
+func FromContext(ctx context.Context) (*Principal, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		if value, exists := c.Get(ginKey); exists {
+			p, ok := value.(*Principal)
+			return p, ok
+		}
+		if c.Request == nil {
+			return nil, false
+		}
+		ctx = c.Request.Context()
+	}
+
+	p, ok := ctx.Value(ctxKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWithPrincipalVisibleFromGinAndRequestContext(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+
+	want := &Principal{UserID: 42, Email: "a@example.com"}
+	WithPrincipal(c, want)
+
+	if got, ok := FromContext(c); !ok || got != want {
+		t.Fatalf("FromContext(gin) = %v, %v; want %v", got, ok, want)
+	}
+	if got, ok := FromContext(c.Request.Context()); !ok || got != want {
+		t.Fatalf("FromContext(request) = %v, %v; want %v", got, ok, want)
+	}
+}
+
+func TestFromContextWithoutPrincipal(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("FromContext(background) returned a principal")
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	if _, ok := FromContext(c); ok {
+		t.Fatal("FromContext(gin) returned a principal")
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	p := &Principal{Permissions: []string{"users:list"}}
+	if !p.HasPermission("users:list") {
+		t.Error("expected users:list")
+	}
+	if p.HasPermission("roles:assign") {
+		t.Error("unexpected roles:assign")
+	}
+
+	admin := &Principal{Permissions: []string{PermissionAll}}
+	if !admin.HasPermission("roles:assign") {
+		t.Error("wildcard permission should grant roles:assign")
+	}
+}
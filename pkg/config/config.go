@@ -1,31 +1,134 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/google/wire"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	Server struct {
-		Port int    `mapstructure:"port"`
-		Mode string `mapstructure:"mode"`
+		Port      int    `mapstructure:"port" default:"8080" validate:"min=1,max=65535"`
+		Mode      string `mapstructure:"mode" default:"debug" validate:"oneof=debug release test"`
+		PublicURL string `mapstructure:"public_url" validate:"omitempty,http_url"` // 对外访问地址，用于生成邮件中的链接
+		// TrustedProxies 可信反向代理的 IP 或 CIDR，只有来自这些地址的 X-Forwarded-For 才会被采信
+		TrustedProxies []string `mapstructure:"trusted_proxies" validate:"dive,ip|cidr"`
 	} `mapstructure:"server"`
 
+	CORS struct {
+		AllowedOrigins   []string      `mapstructure:"allowed_origins"`          // 允许跨域访问的来源，* 表示任意来源；为空时不处理跨域请求
+		AllowedMethods   []string      `mapstructure:"allowed_methods"`          // 默认 GET/POST/PUT/PATCH/DELETE/OPTIONS
+		AllowedHeaders   []string      `mapstructure:"allowed_headers"`          // 默认 Authorization/Content-Type/X-CSRF-Token
+		AllowCredentials bool          `mapstructure:"allow_credentials"`        // 允许携带 Cookie，不能与 * 同时使用
+		MaxAge           time.Duration `mapstructure:"max_age" validate:"min=0"` // 预检请求结果的缓存时长
+	} `mapstructure:"cors"`
+
+	RateLimit struct {
+		Enabled bool    `mapstructure:"enabled"`
+		Rate    float64 `mapstructure:"rate" validate:"min=0,required_if=Enabled true"` // 每个客户端 IP 每秒允许的请求数
+		Burst   int     `mapstructure:"burst" validate:"min=0"`                         // 允许的突发请求数，默认与 rate 相同
+	} `mapstructure:"rate_limit"`
+
 	Database struct {
-		Type string `mapstructure:"type"` // 新增数据库类型字段
-		DSN  string `mapstructure:"dsn"`
+		Type string `mapstructure:"type" validate:"oneof=mysql sqlite postgres"` // 数据库类型: mysql/sqlite/postgres
+		DSN  string `mapstructure:"dsn" secret:"true" validate:"required"`
+		// SlowThreshold 慢查询阈值，默认 200ms，设为负数关闭慢查询日志
+		SlowThreshold time.Duration `mapstructure:"slow_threshold" default:"200ms"`
+
+		MaxOpen         int           `mapstructure:"max_open" default:"25" validate:"min=0"`            // 最大打开连接数，0 表示不限制
+		MaxIdle         int           `mapstructure:"max_idle" default:"10" validate:"min=0"`            // 最大空闲连接数，超过 max_open 时按 max_open 处理
+		ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime" default:"1h" validate:"min=0"`   // 连接最长复用时间，0 表示不限制
+		ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time" default:"10m" validate:"min=0"` // 连接最长空闲时间，0 表示不限制
+		PingRetries     int           `mapstructure:"ping_retries" default:"5" validate:"min=0"`         // 启动时连接失败的重试次数
+		PingBackoff     time.Duration `mapstructure:"ping_backoff" default:"1s" validate:"gt=0"`         // 第一次重试前的等待时间，之后每次翻倍，最长 30s
+
+		// MigrationsDir SQL 迁移文件所在目录，不存在时只执行代码中注册的迁移
+		MigrationsDir string `mapstructure:"migrations_dir" default:"migrations"`
 	} `mapstructure:"database"`
 
 	JWT struct {
-		Secret string `mapstructure:"secret"`
+		Algorithm  string        `mapstructure:"algorithm" default:"HS256" validate:"oneof=HS256 RS256 ES256 EdDSA"` // 签名算法: HS256/RS256/ES256/EdDSA
+		Secret     string        `mapstructure:"secret" secret:"true" validate:"required_if=Algorithm HS256"`        // HS256 使用的共享密钥
+		Issuer     string        `mapstructure:"issuer"`                                                             // 令牌签发者（iss），为空时不校验
+		Keys       []JWTKey      `mapstructure:"keys" validate:"required_unless=Algorithm HS256,dive"`               // 非对称算法使用的密钥，按 kid 区分
+		AccessTTL  time.Duration `mapstructure:"access_ttl" default:"15m" validate:"gt=0"`                           // 访问令牌有效期，如 15m
+		RefreshTTL time.Duration `mapstructure:"refresh_ttl" default:"720h" validate:"gt=0"`                         // 刷新令牌有效期，如 720h
+
+		RevocationStore string `mapstructure:"revocation_store" default:"memory" validate:"oneof=memory gorm"` // 令牌吊销存储: memory/gorm
 	} `mapstructure:"jwt"`
 
+	Auth struct {
+		Mode         string   `mapstructure:"mode" default:"jwt" validate:"oneof=jwt session"` // 登录方式: jwt（默认，签发令牌）/session（服务端会话 + Cookie）
+		Realm        string   `mapstructure:"realm"`                                           // WWW-Authenticate 中的 realm
+		TokenSources []string `mapstructure:"token_sources"`                                   // 令牌来源: header/header:<名称>/cookie:<名称>/query:<名称>
+	} `mapstructure:"auth"`
+
+	Session struct {
+		Store       string        `mapstructure:"store" default:"memory" validate:"oneof=memory gorm file"`   // 会话存储: memory/gorm/file
+		FileDir     string        `mapstructure:"file_dir" default:"storage/sessions"`                        // file 存储的目录，默认 storage/sessions
+		CookieName  string        `mapstructure:"cookie_name" default:"evaframe_session" validate:"required"` // 会话 Cookie 名称，默认 evaframe_session
+		Domain      string        `mapstructure:"domain"`                                                     // Cookie 的 Domain，为空时仅限当前主机
+		Path        string        `mapstructure:"path" default:"/"`                                           // Cookie 的 Path，默认 /
+		Secure      *bool         `mapstructure:"secure"`                                                     // 仅通过 HTTPS 发送，未设置时根据 server.public_url 是否为 https 决定
+		SameSite    string        `mapstructure:"same_site" default:"lax" validate:"oneof=lax strict none"`   // lax（默认）/strict/none，none 要求 secure
+		IdleTimeout time.Duration `mapstructure:"idle_timeout" default:"30m" validate:"gt=0"`                 // 超过该时长没有请求则会话失效，默认 30m
+		Lifetime    time.Duration `mapstructure:"lifetime" default:"24h" validate:"gt=0"`                     // 会话最长有效期，到期后必须重新登录，默认 24h
+		CSRFHeader  string        `mapstructure:"csrf_header" default:"X-CSRF-Token" validate:"required"`     // 携带 CSRF 令牌的请求头，默认 X-CSRF-Token
+	} `mapstructure:"session"`
+
+	MFA struct {
+		Issuer     string        `mapstructure:"issuer"`                                   // 验证器应用中显示的签发者名称
+		PendingTTL time.Duration `mapstructure:"pending_ttl" default:"5m" validate:"gt=0"` // 两步登录临时令牌有效期，如 5m
+	} `mapstructure:"mfa"`
+
+	OIDC struct {
+		Providers []OIDCProvider `mapstructure:"providers" validate:"dive"`
+		Mock      struct {
+			Enabled bool   `mapstructure:"enabled"` // 启用内置模拟 OIDC 服务，仅用于开发和测试
+			Email   string `mapstructure:"email"`   // 未指定 login_hint 时模拟登录的邮箱
+		} `mapstructure:"mock"`
+	} `mapstructure:"oidc"`
+
+	Lockout struct {
+		MaxAttempts   int           `mapstructure:"max_attempts" default:"5" validate:"min=1"`                  // 单个账号连续登录失败多少次后锁定，默认 5
+		IPMaxAttempts int           `mapstructure:"ip_max_attempts" default:"20" validate:"min=1"`              // 单个 IP 连续登录失败多少次后锁定，默认 20
+		BaseDuration  time.Duration `mapstructure:"base_duration" default:"1m" validate:"gt=0"`                 // 第一次锁定时长，之后每次翻倍，默认 1m
+		MaxDuration   time.Duration `mapstructure:"max_duration" default:"1h" validate:"gtefield=BaseDuration"` // 锁定时长上限，默认 1h
+		Window        time.Duration `mapstructure:"window" default:"15m" validate:"gt=0"`                       // 超过该时长没有新的失败则清零，默认 15m
+	} `mapstructure:"lockout"`
+
+	Mail struct {
+		Driver  string `mapstructure:"driver" default:"file" validate:"oneof=smtp file memory"` // 邮件驱动: smtp/file/memory
+		From    string `mapstructure:"from" default:"no-reply@localhost"`                       // 默认发件人
+		FileDir string `mapstructure:"file_dir" default:"storage/mails"`                        // file 驱动的邮件目录
+		SMTP    struct {
+			Host     string `mapstructure:"host" validate:"required_if=Driver smtp"`
+			Port     int    `mapstructure:"port"`
+			Username string `mapstructure:"username"`
+			Password string `mapstructure:"password" secret:"true"`
+		} `mapstructure:"smtp"`
+	} `mapstructure:"mail"`
+
+	Password struct {
+		Algorithm  string `mapstructure:"algorithm" default:"bcrypt" validate:"oneof=bcrypt argon2id"` // 密码哈希算法: bcrypt/argon2id
+		BcryptCost int    `mapstructure:"bcrypt_cost" validate:"omitempty,min=4,max=31"`               // bcrypt 计算成本，0 表示使用默认值
+		Argon2     struct {
+			Memory      uint32 `mapstructure:"memory"`      // 内存开销，单位 KiB
+			Iterations  uint32 `mapstructure:"iterations"`  // 迭代次数
+			Parallelism uint8  `mapstructure:"parallelism"` // 并行度
+			SaltLength  uint32 `mapstructure:"salt_length"` // 盐长度，单位字节
+			KeyLength   uint32 `mapstructure:"key_length"`  // 输出长度，单位字节
+		} `mapstructure:"argon2"`
+	} `mapstructure:"password"`
+
 	Logger struct {
-		Level   string `mapstructure:"level"`
-		LogPath string `mapstructure:"log_path"`
+		Level   string `mapstructure:"level" default:"info" validate:"oneof=debug info warn error"`
+		LogPath string `mapstructure:"log_path" default:"logs/app.log" validate:"required"`
 	} `mapstructure:"logger"`
 
 	DevChoice struct {
@@ -33,27 +136,148 @@ type Config struct {
 	} `mapstructure:"dev_choice"`
 }
 
-func NewConfig(path string) (*Config, error) {
-	v := viper.New()
-	v.SetConfigFile(path)
-	v.SetConfigType("yaml")
+// OIDCProvider 外部 OpenID Connect 提供方
+type OIDCProvider struct {
+	Name         string   `mapstructure:"name" validate:"required"`            // 提供方名称，用于登录地址 /oidc/:name/login
+	Issuer       string   `mapstructure:"issuer" validate:"required,http_url"` // 签发方地址，通过 /.well-known/openid-configuration 发现端点
+	ClientID     string   `mapstructure:"client_id" validate:"required"`
+	ClientSecret string   `mapstructure:"client_secret" secret:"true"`
+	Scopes       []string `mapstructure:"scopes"`                                     // 默认 openid email profile
+	RedirectURL  string   `mapstructure:"redirect_url" validate:"omitempty,http_url"` // 默认 <public_url>/api/v1/oidc/<name>/callback
+}
+
+// JWTKey 非对称签名密钥配置
+type JWTKey struct {
+	KID            string `mapstructure:"kid" validate:"required"`
+	PrivateKey     string `mapstructure:"private_key" secret:"true" validate:"required_without=PrivateKeyFile"` // PEM 格式私钥
+	PrivateKeyFile string `mapstructure:"private_key_file"`                                                     // PEM 格式私钥文件路径，优先于 private_key
+	NotBefore      string `mapstructure:"not_before" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`   // RFC3339，从该时间起用于签名，之前只发布公钥
+	ExpiresAt      string `mapstructure:"expires_at" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`   // RFC3339，到期后不再发布也不再用于验签
+}
+
+// NewConfig 读取配置文件并应用环境变量和命令行覆盖项，校验通过后返回，不监听变化
+func NewConfig(opts Options) (*Config, error) {
+	c, _, err := build(opts)
+	return c, err
+}
+
+// Watch 读取配置并监听参与合并的所有配置文件和配置源，任一变化后重新加载并交给 Holder 校验和替换
+func Watch(opts Options) (*Holder, error) {
+	c, l, err := build(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	h := NewHolder(c)
+
+	// 文件和配置源可能同时变化，串行重新加载，避免较早读取的配置覆盖较新的配置
+	var mu sync.Mutex
+	reload := func(name string) *loaded {
+		mu.Lock()
+		defer mu.Unlock()
+
+		next, l, err := build(opts)
+		if err == nil {
+			err = h.Update(next)
+		}
+		if err != nil {
+			h.reportError(fmt.Errorf("%s: %w", name, err))
+			return nil
+		}
+		return l
+	}
+
+	// 重新加载成功后按新的结果监听，include 或 profile 可能引入了新的文件
+	if err := watchFiles(l, reload); err != nil {
+		return nil, err
+	}
+	for _, src := range opts.Sources {
+		go func() {
+			err := src.Watch(context.Background(), func() { reload(src.Name()) })
+			if err != nil {
+				h.reportError(fmt.Errorf("stopped watching config source %s: %w", src.Name(), err))
+			}
+		}()
+	}
+	return h, nil
+}
+
+// build 加载、解码配置，解析其中的密钥引用后校验
+func build(opts Options) (*Config, *loaded, error) {
+	l, err := load(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := unmarshal(l.v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secretFiles, err := resolveSecrets(c, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	l.files = append(l.files, secretFiles...)
 
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	if err := Validate(c); err != nil {
+		return nil, nil, err
 	}
+	return c, l, nil
+}
 
-	// 设置热更新
-	v.WatchConfig()
-	v.OnConfigChange(func(e fsnotify.Event) {
-		fmt.Println("Config file changed:", e.Name)
-	})
+// loaded 合并后的配置及各配置项的来源
+type loaded struct {
+	v       *viper.Viper
+	origins map[string]Origin // 被配置源、环境变量或命令行覆盖的配置项
+	setBy   map[string]string // 配置项 → 最后设置它的配置文件或配置源
+	files   []string          // 参与合并的所有配置文件，以及 file:// 引用的密钥文件
+	globs   []string          // include 中的通配符
+}
 
+// load 依次合并默认值、配置文件（include 的片段、主文件、profile 覆盖文件），再应用配置源、环境变量和命令行覆盖项
+func load(opts Options) (*loaded, error) {
+	layers, err := readLayers(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loaded{v: viper.New(), setBy: make(map[string]string)}
+	setDefaults(l.v)
+	for _, layer := range layers {
+		if err := l.v.MergeConfigMap(layer.settings); err != nil {
+			return nil, fmt.Errorf("failed to merge config file %s: %w", layer.path, err)
+		}
+		for _, key := range layer.keys {
+			l.setBy[key] = layer.path
+		}
+		l.files = append(l.files, layer.path)
+		l.globs = append(l.globs, layer.patterns...)
+	}
+
+	remote, err := loadSources(opts.Sources)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyOverrides(l, opts, remote); err != nil {
+		return nil, fmt.Errorf("invalid config override: %w", err)
+	}
+	return l, nil
+}
+
+func unmarshal(v *viper.Viper) (*Config, error) {
 	var c Config
 	if err := v.Unmarshal(&c); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
-
 	return &c, nil
 }
 
-var ProviderSet = wire.NewSet(NewConfig)
+var ProviderSet = wire.NewSet(Watch, Current)
+
+// BaseURL 返回服务对外访问的根地址（不含末尾斜杠），未配置 public_url 时使用本机端口
+func (c *Config) BaseURL() string {
+	if c.Server.PublicURL != "" {
+		return strings.TrimRight(c.Server.PublicURL, "/")
+	}
+	return fmt.Sprintf("http://localhost:%d", c.Server.Port)
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DirSource 用本地目录模拟键值存储，用于开发和测试。每个文件是一个配置项，
+// 相对路径即键（server/port 或 server.port），文件内容为值；以 . 开头的文件和目录被忽略
+type DirSource struct {
+	dir string
+}
+
+// NewDirSource 创建目录配置源
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+// newDirSourceFromURL 支持 dir:config/remote 和 dir:///etc/evaframe 两种写法
+func newDirSourceFromURL(u *url.URL) (Source, error) {
+	dir := u.Opaque
+	if dir == "" {
+		if u.Host != "" {
+			return nil, fmt.Errorf("invalid dir source %q, use dir:relative/path or dir:///absolute/path", u.String())
+		}
+		dir = u.Path
+	}
+	if dir == "" {
+		return nil, errors.New("dir source requires a directory")
+	}
+	return NewDirSource(dir), nil
+}
+
+// Name 配置源名称
+func (s *DirSource) Name() string {
+	return "dir:" + s.dir
+}
+
+// Load 读取目录中的所有配置项
+func (s *DirSource) Load(ctx context.Context) (map[string]string, error) {
+	values := make(map[string]string)
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == s.dir {
+			return nil
+		}
+		if ignored(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		key := strings.ReplaceAll(filepath.ToSlash(rel), "/", ".")
+		values[key] = strings.TrimRight(string(data), "\r\n")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Watch 监听目录及其子目录，文件新增、修改或删除时调用 notify
+func (s *DirSource) Watch(ctx context.Context, notify func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := addDirs(watcher, s.dir); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// 不过滤隐藏文件：Kubernetes ConfigMap 通过替换 ..data 符号链接整体更新
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// 新建的子目录同样需要监听
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addDirs(watcher, event.Name); err != nil {
+						return err
+					}
+				}
+			}
+			notify()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// addDirs 监听 root 及其下所有未被忽略的子目录
+func addDirs(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && ignored(d.Name()) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// ignored 忽略隐藏文件和编辑器的临时文件
+func ignored(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// Holder 线程安全的配置持有者，配置文件变化时整体替换当前配置并通知订阅者
+//
+// Get 返回的 *Config 在替换后不会再被修改，可以放心地在请求中持有。
+// 新配置先经过所有校验函数，任一校验失败则丢弃新配置、保留当前配置。
+type Holder struct {
+	mu          sync.RWMutex
+	current     *Config
+	validators  []func(*Config) error
+	subscribers []func(old, next *Config)
+	onError     func(error)
+}
+
+// NewHolder 创建配置持有者
+func NewHolder(cfg *Config) *Holder {
+	return &Holder{
+		current: cfg,
+		onError: func(err error) {
+			fmt.Fprintln(os.Stderr, "Config reload rejected:", err)
+		},
+	}
+}
+
+// Current 返回启动时的配置 Provider，不随热更新变化的组件直接依赖 *Config
+func Current(h *Holder) *Config {
+	return h.Get()
+}
+
+// Get 返回当前配置
+func (h *Holder) Get() *Config {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.current
+}
+
+// AddValidator 注册校验函数，用于拒绝当前组件无法应用的配置
+func (h *Holder) AddValidator(fn func(*Config) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.validators = append(h.validators, fn)
+}
+
+// Subscribe 注册配置变化的回调，在替换配置后按注册顺序同步调用
+func (h *Holder) Subscribe(fn func(old, next *Config)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers = append(h.subscribers, fn)
+}
+
+// OnError 设置热更新失败时的处理函数，默认输出到标准错误
+func (h *Holder) OnError(fn func(error)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.onError = fn
+}
+
+// Update 校验并替换当前配置，校验失败时保留当前配置并返回所有错误
+func (h *Holder) Update(next *Config) error {
+	h.mu.Lock()
+	var errs []error
+	for _, validate := range h.validators {
+		if err := validate(next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		h.mu.Unlock()
+		return errors.Join(errs...)
+	}
+
+	old := h.current
+	h.current = next
+	subscribers := h.subscribers
+	h.mu.Unlock()
+
+	// 回调中可能再次调用 Get，不能持有锁
+	for _, fn := range subscribers {
+		fn(old, next)
+	}
+	return nil
+}
+
+// reportError 交给 OnError 设置的处理函数
+func (h *Holder) reportError(err error) {
+	h.mu.RLock()
+	onError := h.onError
+	h.mu.RUnlock()
+	onError(err)
+}
+
+// OnChange 订阅配置中的某一部分，只有该部分的值发生变化时才调用 fn
+//
+//	config.OnChange(holder, func(c *config.Config) string { return c.Logger.Level }, func(level string) { ... })
+func OnChange[T any](h *Holder, section func(*Config) T, fn func(T)) {
+	h.Subscribe(func(old, next *Config) {
+		value := section(next)
+		if !reflect.DeepEqual(section(old), value) {
+			fn(value)
+		}
+	})
+}
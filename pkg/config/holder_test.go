@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateRejectsInvalidConfig(t *testing.T) {
+	initial := &Config{}
+	initial.Logger.Level = "info"
+	h := NewHolder(initial)
+	h.AddValidator(func(c *Config) error {
+		if c.Logger.Level == "loud" {
+			return errors.New("unknown level")
+		}
+		return nil
+	})
+
+	notified := 0
+	h.Subscribe(func(old, next *Config) { notified++ })
+
+	bad := &Config{}
+	bad.Logger.Level = "loud"
+	if err := h.Update(bad); err == nil {
+		t.Fatal("want validation error")
+	}
+	if h.Get() != initial || notified != 0 {
+		t.Fatal("rejected config must keep the previous config and not notify subscribers")
+	}
+
+	good := &Config{}
+	good.Logger.Level = "debug"
+	if err := h.Update(good); err != nil {
+		t.Fatal(err)
+	}
+	if h.Get() != good || notified != 1 {
+		t.Fatalf("config = %+v, notified = %d, want new config and one notification", h.Get().Logger, notified)
+	}
+}
+
+func TestOnChangeOnlyFiresWhenSectionChanges(t *testing.T) {
+	initial := &Config{}
+	initial.Logger.Level = "info"
+	h := NewHolder(initial)
+
+	var got []string
+	OnChange(h, func(c *Config) string { return c.Logger.Level }, func(level string) {
+		got = append(got, level)
+	})
+
+	same := &Config{}
+	same.Logger.Level = "info"
+	same.Server.Port = 9090
+	changed := &Config{}
+	changed.Logger.Level = "warn"
+
+	for _, next := range []*Config{same, changed} {
+		if err := h.Update(next); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 1 || got[0] != "warn" {
+		t.Fatalf("got = %v, want [warn]", got)
+	}
+}
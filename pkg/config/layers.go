@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ProfileEnv 未指定 --profile 时从该环境变量读取 profile
+const ProfileEnv = "EVAFRAME_PROFILE"
+
+// includeKey 配置文件中引用其他配置片段的配置项
+const includeKey = "include"
+
+// formats 支持的配置文件格式，按扩展名识别
+var formats = map[string]string{
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+	".json": "json",
+}
+
+// layer 单个配置文件读取到的内容
+type layer struct {
+	path     string
+	settings map[string]any
+	keys     []string
+	patterns []string // include 中的通配符，匹配的文件增减时需要重新加载
+}
+
+// readLayers 按优先级从低到高返回主配置文件和 profile 覆盖文件，以及它们 include 的片段
+func readLayers(opts Options) ([]layer, error) {
+	layers, err := readFile(opts.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+	if profile == "" {
+		return layers, nil
+	}
+
+	path, err := profilePath(opts.Path, profile)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := readFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(layers, overlay...), nil
+}
+
+// profilePath 返回 profile 覆盖文件的路径：config.yaml 的 prod 覆盖文件为同目录下的 config.prod.yaml，
+// 也可以使用其他支持的格式，如 config.prod.toml
+func profilePath(base, profile string) (string, error) {
+	if strings.ContainsAny(profile, `/\`) || strings.HasPrefix(profile, ".") {
+		return "", fmt.Errorf("invalid profile name %q", profile)
+	}
+
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	candidates := []string{stem + "." + profile + ext}
+	for _, e := range sortedExts() {
+		if e != ext {
+			candidates = append(candidates, stem+"."+profile+e)
+		}
+	}
+
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("config file for profile %q not found, expected %s", profile, candidates[0])
+}
+
+func sortedExts() []string {
+	exts := make([]string, 0, len(formats))
+	for ext := range formats {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// readFile 读取配置文件，include 的片段排在前面，因此文件自身的配置优先于它引用的片段
+func readFile(path string, stack []string) ([]layer, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(stack, abs) {
+		return nil, fmt.Errorf("config include cycle: %s", strings.Join(append(stack, abs), " -> "))
+	}
+	stack = append(stack, abs)
+
+	format, err := formatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(format)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var (
+		layers   []layer
+		patterns []string
+	)
+	for _, pattern := range v.GetStringSlice(includeKey) {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		if isGlob(pattern) {
+			patterns = append(patterns, pattern)
+		}
+		paths, err := expandInclude(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, p := range paths {
+			included, err := readFile(p, stack)
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, included...)
+		}
+	}
+
+	settings := v.AllSettings()
+	delete(settings, includeKey)
+	keys := slices.DeleteFunc(v.AllKeys(), func(key string) bool { return key == includeKey })
+	return append(layers, layer{path: path, settings: settings, keys: keys, patterns: patterns}), nil
+}
+
+// expandInclude 展开 include 中的通配符，如 conf.d/*.yaml 按文件名排序；不含通配符的路径必须存在
+func expandInclude(pattern string) ([]string, error) {
+	if !isGlob(pattern) {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, fmt.Errorf("include %s: %w", pattern, err)
+		}
+		return []string{pattern}, nil
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("include %s: %w", pattern, err)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// formatOf 根据扩展名返回配置文件格式，没有扩展名时按 YAML 解析
+func formatOf(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return "yaml", nil
+	}
+	if format, ok := formats[ext]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported config file format %q, use .yaml, .yml, .toml or .json", ext)
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestProfileOverlayIsDeepMerged profile 文件只覆盖自己写出的配置项，同一节中的其他配置项保留主文件的值
+func TestProfileOverlayIsDeepMerged(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml":      baseConfig + "server:\n  port: 8080\n  mode: debug\njwt:\n  secret: dev\n",
+		"config.prod.toml": "[server]\nmode = \"release\"\n\n[jwt]\nsecret = \"prod\"\n",
+	})
+
+	c, err := NewConfig(Options{Path: filepath.Join(dir, "config.yaml"), Profile: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Port != 8080 || c.Server.Mode != "release" || c.JWT.Secret != "prod" {
+		t.Fatalf("port = %d, mode = %q, secret = %q", c.Server.Port, c.Server.Mode, c.JWT.Secret)
+	}
+}
+
+func TestProfileFromEnv(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.json":         `{"database": {"type": "sqlite", "dsn": "test.db"}, "jwt": {"secret": "s"}}`,
+		"config.staging.json": `{"server": {"port": 9000}}`,
+	})
+	t.Setenv(ProfileEnv, "staging")
+
+	c, err := NewConfig(Options{Path: filepath.Join(dir, "config.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Port != 9000 {
+		t.Fatalf("port = %d, want 9000 from the staging profile", c.Server.Port)
+	}
+
+	if _, err := NewConfig(Options{Path: filepath.Join(dir, "config.json"), Profile: "missing"}); err == nil {
+		t.Fatal("want error for a profile without a config file")
+	}
+}
+
+// TestIncludeFragments 文件自身的配置优先于 include 的片段，片段按文件名顺序合并
+func TestIncludeFragments(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml":        "include:\n  - conf.d/*.yaml\njwt:\n  secret: main\n",
+		"conf.d/10-db.yaml":  baseConfig,
+		"conf.d/20-jwt.yaml": "jwt:\n  secret: fragment\n  issuer: evaframe\nserver:\n  port: 7000\n",
+		"conf.d/30-srv.yaml": "server:\n  port: 7001\n",
+	})
+
+	path := filepath.Join(dir, "config.yaml")
+	c, err := NewConfig(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.JWT.Secret != "main" || c.JWT.Issuer != "evaframe" || c.Server.Port != 7001 || c.Database.Type != "sqlite" {
+		t.Fatalf("secret = %q, issuer = %q, port = %d, database = %q", c.JWT.Secret, c.JWT.Issuer, c.Server.Port, c.Database.Type)
+	}
+
+	settings, err := Explain(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range settings {
+		if s.Key == "server.port" && filepath.Base(s.Location) != "30-srv.yaml" {
+			t.Fatalf("server.port file = %q, want 30-srv.yaml", s.Location)
+		}
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": "include: [a.yaml]\n",
+		"a.yaml":      "include: [config.yaml]\n",
+	})
+	_, err := NewConfig(Options{Path: filepath.Join(dir, "config.yaml")})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("err = %v, want include cycle error", err)
+	}
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// EnvPrefix 环境变量前缀，server.port 对应 EVAFRAME_SERVER_PORT
+const EnvPrefix = "EVAFRAME_"
+
+// Options 配置加载选项，优先级从低到高为：配置文件 < profile 覆盖文件 < 配置源 < 环境变量 < Overrides
+type Options struct {
+	Path      string   // 主配置文件路径，支持 .yaml/.yml/.toml/.json
+	Profile   string   // profile 名称，如 prod 时合并同目录下的 config.prod.yaml；为空时读取 EVAFRAME_PROFILE
+	Overrides []string // 命令行 --set 传入的 key=value，如 server.port=9090
+	Sources   []Source // 远程配置源，按顺序合并，后面的优先
+}
+
+// Origin 配置项的来源
+type Origin string
+
+const (
+	OriginDefault Origin = "default"
+	OriginFile    Origin = "file"
+	OriginSource  Origin = "source"
+	OriginEnv     Origin = "env"
+	OriginFlag    Origin = "flag"
+)
+
+// Setting 生效的配置项及其来源，用于 config show
+type Setting struct {
+	Key      string
+	Value    string
+	Origin   Origin
+	Location string // 来源为 file 或 source 时，最后设置该配置项的配置文件或配置源
+}
+
+// leaf 配置结构中的叶子字段
+type leaf struct {
+	key   string
+	field reflect.StructField
+	value reflect.Value
+}
+
+// leaves 按 mapstructure 标签展开配置结构，嵌套结构体继续展开，其余字段（包括结构体切片）作为叶子
+func leaves(v reflect.Value, prefix string) []leaf {
+	var out []leaf
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if prefix != "" {
+			key = prefix + "." + tag
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			out = append(out, leaves(fv, key)...)
+			continue
+		}
+		out = append(out, leaf{key: key, field: field, value: fv})
+	}
+	return out
+}
+
+// EnvName 返回配置项对应的环境变量名
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// parseOverrides 解析 key=value 形式的覆盖项，同一个 key 以最后一次为准
+func parseOverrides(overrides []string, known map[string]reflect.Type) (map[string]string, error) {
+	out := make(map[string]string, len(overrides))
+	for _, o := range overrides {
+		key, value, ok := strings.Cut(o, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid override %q, expected key=value", o)
+		}
+		if _, exists := known[key]; !exists {
+			return nil, fmt.Errorf("unknown config key %q", key)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+// overrideValue 把字符串转换为 viper 能解码的值，结构体切片（如 jwt.keys）使用 JSON 数组
+func overrideValue(key, raw string, t reflect.Type) (any, error) {
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct {
+		var items []map[string]any
+		if err := json.Unmarshal([]byte(raw), &items); err != nil {
+			return nil, fmt.Errorf("%s must be a JSON array: %w", key, err)
+		}
+		return items, nil
+	}
+	return raw, nil
+}
+
+// applyOverrides 依次应用配置源、环境变量和命令行覆盖项，并记录被覆盖配置项的来源
+func applyOverrides(l *loaded, opts Options, remote []sourceValues) error {
+	known := make(map[string]reflect.Type)
+	var keys []string
+	for _, lf := range leaves(reflect.ValueOf(Config{}), "") {
+		known[lf.key] = lf.value.Type()
+		keys = append(keys, lf.key)
+	}
+
+	flags, err := parseOverrides(opts.Overrides, known)
+	if err != nil {
+		return err
+	}
+
+	l.origins = make(map[string]Origin)
+	set := func(key, raw string, origin Origin) error {
+		value, err := overrideValue(key, raw, known[key])
+		if err != nil {
+			return fmt.Errorf("%s: %w", origin, err)
+		}
+		l.v.Set(key, value)
+		l.origins[key] = origin
+		return nil
+	}
+
+	for _, src := range remote {
+		for key, raw := range src.values {
+			if _, ok := known[key]; !ok {
+				return fmt.Errorf("source %s: unknown config key %q", src.name, key)
+			}
+			if err := set(key, raw, OriginSource); err != nil {
+				return fmt.Errorf("source %s: %w", src.name, err)
+			}
+			l.setBy[key] = src.name
+		}
+	}
+
+	// 与 viper 一致，空的环境变量视为未设置
+	for _, key := range keys {
+		if raw := os.Getenv(EnvName(key)); raw != "" {
+			if err := set(key, raw, OriginEnv); err != nil {
+				return err
+			}
+		}
+	}
+	for key, raw := range flags {
+		if err := set(key, raw, OriginFlag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Explain 加载配置并返回每个配置项的生效值和来源。不解析密钥引用，secret 字段只显示引用或是否已设置
+func Explain(opts Options) ([]Setting, error) {
+	l, err := load(opts)
+	if err != nil {
+		return nil, err
+	}
+	c, err := unmarshal(l.v)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings []Setting
+	for _, lf := range leaves(reflect.ValueOf(*c), "") {
+		origin, ok := l.origins[lf.key]
+		if !ok {
+			origin = OriginDefault
+			if _, ok := l.setBy[lf.key]; ok {
+				origin = OriginFile
+			}
+		}
+		location := ""
+		if origin == OriginFile || origin == OriginSource {
+			location = l.setBy[lf.key]
+		}
+
+		// 结构体切片逐个元素展开，来源与整个切片相同
+		if lf.value.Kind() == reflect.Slice && lf.value.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < lf.value.Len(); i++ {
+				for _, item := range leaves(lf.value.Index(i), fmt.Sprintf("%s[%d]", lf.key, i)) {
+					settings = append(settings, Setting{Key: item.key, Value: formatValue(item), Origin: origin, Location: location})
+				}
+			}
+			continue
+		}
+		settings = append(settings, Setting{Key: lf.key, Value: formatValue(lf), Origin: origin, Location: location})
+	}
+	return settings, nil
+}
+
+// formatValue 格式化配置项的值，secret 字段不显示实际的值
+func formatValue(l leaf) string {
+	v := l.value
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if isSecret(l) {
+		return formatSecret(v.String())
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return value.String()
+	case []string:
+		return strings.Join(value, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// baseConfig 通过校验所需的最少配置
+const baseConfig = "database:\n  type: sqlite\n  dsn: test.db\n"
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	content = baseConfig + content
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestOverridePrecedence 优先级：配置文件 < 环境变量 < --set
+func TestOverridePrecedence(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 8080\n  mode: debug\njwt:\n  secret: s3cret\nlogger:\n  level: info\n")
+	t.Setenv("EVAFRAME_SERVER_PORT", "7000")
+	t.Setenv("EVAFRAME_LOGGER_LEVEL", "warn")
+	t.Setenv("EVAFRAME_CORS_ALLOWED_ORIGINS", "https://a.example.com,https://b.example.com")
+
+	c, err := NewConfig(Options{Path: path, Overrides: []string{"server.port=9090"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Port != 9090 || c.Server.Mode != "debug" || c.Logger.Level != "warn" {
+		t.Fatalf("port = %d, mode = %q, level = %q", c.Server.Port, c.Server.Mode, c.Logger.Level)
+	}
+	if len(c.CORS.AllowedOrigins) != 2 {
+		t.Fatalf("allowed origins = %v, want two entries", c.CORS.AllowedOrigins)
+	}
+}
+
+func TestOverrideStructSliceFromJSON(t *testing.T) {
+	path := writeConfig(t, "jwt:\n  algorithm: RS256\n")
+	c, err := NewConfig(Options{Path: path, Overrides: []string{`jwt.keys=[{"kid":"k1","private_key_file":"k1.pem"}]`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.JWT.Keys) != 1 || c.JWT.Keys[0].KID != "k1" || c.JWT.Keys[0].PrivateKeyFile != "k1.pem" {
+		t.Fatalf("keys = %+v", c.JWT.Keys)
+	}
+}
+
+func TestOverrideRejectsUnknownKey(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 8080\n")
+	for _, o := range []string{"server.prot=1", "server.port"} {
+		if _, err := NewConfig(Options{Path: path, Overrides: []string{o}}); err == nil {
+			t.Fatalf("override %q: want error", o)
+		}
+	}
+}
+
+func TestExplainReportsOriginsAndMasksSecrets(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 8080\njwt:\n  secret: s3cret\n")
+	t.Setenv("EVAFRAME_SERVER_MODE", "release")
+
+	settings, err := Explain(Options{Path: path, Overrides: []string{"logger.level=debug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]Setting)
+	for _, s := range settings {
+		s.Location = ""
+		got[s.Key] = s
+	}
+
+	want := map[string]Setting{
+		"server.port":   {Key: "server.port", Value: "8080", Origin: OriginFile},
+		"server.mode":   {Key: "server.mode", Value: "release", Origin: OriginEnv},
+		"logger.level":  {Key: "logger.level", Value: "debug", Origin: OriginFlag},
+		"jwt.secret":    {Key: "jwt.secret", Value: "******", Origin: OriginFile},
+		"database.dsn":  {Key: "database.dsn", Value: "******", Origin: OriginFile},
+		"jwt.issuer":    {Key: "jwt.issuer", Value: "", Origin: OriginDefault},
+		"jwt.algorithm": {Key: "jwt.algorithm", Value: "HS256", Origin: OriginDefault},
+	}
+	for key, w := range want {
+		if got[key] != w {
+			t.Errorf("%s = %+v, want %+v", key, got[key], w)
+		}
+	}
+}
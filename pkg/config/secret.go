@@ -0,0 +1,213 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	// MasterKeyEnv 主密钥（base64 编码的 32 字节），优先于主密钥文件
+	MasterKeyEnv = "EVAFRAME_MASTER_KEY"
+	// MasterKeyFileEnv 主密钥文件路径，默认为主配置文件同目录下的 master.key
+	MasterKeyFileEnv = "EVAFRAME_MASTER_KEY_FILE"
+
+	defaultMasterKeyFile = "master.key"
+	masterKeySize        = 32
+
+	secretFilePrefix = "file://"
+	secretEnvPrefix  = "env://"
+	secretEncPrefix  = "enc:v1:"
+)
+
+// secretLeaves 返回带 secret:"true" 标签的字符串字段，结构体切片逐个元素展开
+func secretLeaves(v reflect.Value, prefix string) []leaf {
+	var out []leaf
+	for _, l := range leaves(v, prefix) {
+		if l.value.Kind() == reflect.Slice && l.value.Type().Elem().Kind() == reflect.Struct {
+			for i := 0; i < l.value.Len(); i++ {
+				out = append(out, secretLeaves(l.value.Index(i), fmt.Sprintf("%s[%d]", l.key, i))...)
+			}
+			continue
+		}
+		if isSecret(l) && l.value.Kind() == reflect.String {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func isSecret(l leaf) bool {
+	return l.field.Tag.Get("secret") == "true"
+}
+
+// resolveSecrets 把 secret 字段中的引用替换为实际的值，返回引用到的文件以便热更新时一起监听。
+// 错误信息只包含配置项和引用，不包含密钥本身
+func resolveSecrets(c *Config, opts Options) ([]string, error) {
+	var (
+		files     []string
+		errs      []error
+		masterKey []byte
+		keyErr    error
+	)
+	for _, l := range secretLeaves(reflect.ValueOf(c).Elem(), "") {
+		raw := l.value.String()
+		var (
+			value string
+			err   error
+		)
+		switch {
+		case strings.HasPrefix(raw, secretFilePrefix):
+			path := strings.TrimPrefix(raw, secretFilePrefix)
+			var data []byte
+			if data, err = os.ReadFile(path); err == nil {
+				value = strings.TrimRight(string(data), "\r\n")
+				files = append(files, path)
+			}
+		case strings.HasPrefix(raw, secretEnvPrefix):
+			name := strings.TrimPrefix(raw, secretEnvPrefix)
+			if value = os.Getenv(name); value == "" {
+				err = fmt.Errorf("environment variable %s is not set", name)
+			}
+		case strings.HasPrefix(raw, secretEncPrefix):
+			if masterKey == nil && keyErr == nil {
+				masterKey, _, keyErr = LoadMasterKey(opts)
+			}
+			if err = keyErr; err == nil {
+				value, err = Decrypt(masterKey, raw)
+			}
+		default:
+			continue
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", l.key, err))
+			continue
+		}
+		l.value.SetString(value)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", errors.Join(errs...))
+	}
+	return files, nil
+}
+
+// formatSecret 展示 secret 字段：引用原样显示，其余只显示是否已设置
+func formatSecret(raw string) string {
+	switch {
+	case raw == "":
+		return ""
+	case strings.HasPrefix(raw, secretFilePrefix), strings.HasPrefix(raw, secretEnvPrefix):
+		return raw
+	case strings.HasPrefix(raw, secretEncPrefix):
+		return "****** (encrypted)"
+	default:
+		return "******"
+	}
+}
+
+// MasterKeyPath 返回主密钥文件路径
+func MasterKeyPath(opts Options) string {
+	if path := os.Getenv(MasterKeyFileEnv); path != "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(opts.Path), defaultMasterKeyFile)
+}
+
+// LoadMasterKey 读取主密钥，返回密钥和它的来源
+func LoadMasterKey(opts Options) ([]byte, string, error) {
+	if encoded := os.Getenv(MasterKeyEnv); encoded != "" {
+		key, err := decodeMasterKey(encoded)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", MasterKeyEnv, err)
+		}
+		return key, "$" + MasterKeyEnv, nil
+	}
+
+	path := MasterKeyPath(opts)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read master key: %w", err)
+	}
+	key, err := decodeMasterKey(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, "", fmt.Errorf("master key %s: %w", path, err)
+	}
+	return key, path, nil
+}
+
+func decodeMasterKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != masterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes encoded in base64", masterKeySize)
+	}
+	return key, nil
+}
+
+// GenerateMasterKey 生成新的主密钥并写入 path，文件已存在时返回错误
+func GenerateMasterKey(path string) ([]byte, error) {
+	key := make([]byte, masterKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create master key: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		return nil, fmt.Errorf("failed to write master key: %w", err)
+	}
+	return key, nil
+}
+
+// Encrypt 使用 AES-256-GCM 加密配置值，返回可以直接写入配置文件的 enc:v1:<base64> 字符串
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretEncPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 生成的字符串
+func Decrypt(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, secretEncPrefix))
+	if err != nil {
+		return "", errors.New("encrypted value is not valid base64")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt value, wrong master key or corrupted ciphertext")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
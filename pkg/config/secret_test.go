@@ -0,0 +1,92 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newMasterKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, masterKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := newMasterKey(t)
+	encrypted, err := Encrypt(key, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encrypted, secretEncPrefix) || strings.Contains(encrypted, "s3cret") {
+		t.Fatalf("encrypted = %q", encrypted)
+	}
+
+	plaintext, err := Decrypt(key, encrypted)
+	if err != nil || plaintext != "s3cret" {
+		t.Fatalf("decrypt = %q, %v", plaintext, err)
+	}
+	if _, err := Decrypt(newMasterKey(t), encrypted); err == nil {
+		t.Fatal("want error when decrypting with another key")
+	}
+}
+
+func TestResolveSecretReferences(t *testing.T) {
+	key := newMasterKey(t)
+	t.Setenv(MasterKeyEnv, base64.StdEncoding.EncodeToString(key))
+	t.Setenv("TEST_SMTP_PASSWORD", "smtp-pass")
+	encrypted, err := Encrypt(key, "jwt-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	dsnFile := filepath.Join(dir, "db_dsn")
+	if err := os.WriteFile(dsnFile, []byte("app.db\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	content := "database:\n  type: sqlite\n  dsn: file://" + dsnFile + "\n" +
+		"jwt:\n  secret: " + encrypted + "\n" +
+		"mail:\n  smtp:\n    password: env://TEST_SMTP_PASSWORD\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewConfig(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Database.DSN != "app.db" || c.JWT.Secret != "jwt-secret" || c.Mail.SMTP.Password != "smtp-pass" {
+		t.Fatalf("dsn = %q, secret = %q, smtp password = %q", c.Database.DSN, c.JWT.Secret, c.Mail.SMTP.Password)
+	}
+
+	// config show 不解析引用，也不显示密钥
+	settings, err := Explain(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range settings {
+		if strings.Contains(s.Value, "jwt-secret") || strings.Contains(s.Value, "smtp-pass") || s.Value == "app.db" {
+			t.Fatalf("%s leaks a secret: %q", s.Key, s.Value)
+		}
+	}
+}
+
+// TestResolveErrorsDoNotLeakSecrets 解析失败时错误信息只包含配置项，不包含其他已解析的密钥
+func TestResolveErrorsDoNotLeakSecrets(t *testing.T) {
+	path := writeConfig(t, "jwt:\n  secret: plain-jwt-secret\nmail:\n  smtp:\n    password: env://TEST_MISSING_PASSWORD\n")
+	_, err := NewConfig(Options{Path: path})
+	if err == nil || !strings.Contains(err.Error(), "mail.smtp.password") {
+		t.Fatalf("err = %v, want an error for mail.smtp.password", err)
+	}
+	if strings.Contains(err.Error(), "plain-jwt-secret") {
+		t.Fatalf("error leaks a secret: %v", err)
+	}
+}
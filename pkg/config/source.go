@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SourcesEnv 未指定 --source 时从该环境变量读取逗号分隔的配置源地址
+	SourcesEnv = "EVAFRAME_CONFIG_SOURCES"
+
+	// sourceLoadTimeout 每次从配置源读取的超时时间
+	sourceLoadTimeout = 10 * time.Second
+)
+
+// Source 配置源，如 etcd、Consul 等键值存储。配置源中的值按配置项覆盖配置文件，
+// 优先级低于环境变量和命令行，变化后与配置文件走同一套重新加载、校验和替换流程
+type Source interface {
+	// Name 配置源的名称，出现在 config show 和错误信息中
+	Name() string
+	// Load 读取全部配置项，键为以 . 分隔的配置路径（如 server.port），值的格式与环境变量相同
+	Load(ctx context.Context) (map[string]string, error)
+	// Watch 阻塞监听配置变化，每次变化调用 notify，ctx 取消时返回
+	Watch(ctx context.Context, notify func()) error
+}
+
+// SourceFactory 根据地址创建配置源
+type SourceFactory func(u *url.URL) (Source, error)
+
+var (
+	sourcesMu       sync.RWMutex
+	sourceFactories = map[string]SourceFactory{"dir": newDirSourceFromURL}
+)
+
+// RegisterSource 注册配置源，之后可以通过 --source <scheme>:... 使用，如 etcd、consul
+func RegisterSource(scheme string, factory SourceFactory) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sourceFactories[scheme] = factory
+}
+
+// OpenSource 根据地址创建配置源，如 dir:config/remote
+func OpenSource(rawURL string) (Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config source %q: %w", rawURL, err)
+	}
+
+	sourcesMu.RLock()
+	factory, ok := sourceFactories[u.Scheme]
+	sourcesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown config source %q, available: %s", u.Scheme, strings.Join(sourceSchemes(), ", "))
+	}
+	return factory(u)
+}
+
+func sourceSchemes() []string {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	schemes := make([]string, 0, len(sourceFactories))
+	for scheme := range sourceFactories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// sourceValues 单个配置源读取到的配置项
+type sourceValues struct {
+	name   string
+	values map[string]string
+}
+
+// loadSources 依次读取所有配置源
+func loadSources(sources []Source) ([]sourceValues, error) {
+	out := make([]sourceValues, 0, len(sources))
+	for _, src := range sources {
+		ctx, cancel := context.WithTimeout(context.Background(), sourceLoadTimeout)
+		values, err := src.Load(ctx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config source %s: %w", src.Name(), err)
+		}
+
+		normalized := make(map[string]string, len(values))
+		for key, value := range values {
+			normalized[strings.ToLower(key)] = value
+		}
+		out = append(out, sourceValues{name: src.Name(), values: normalized})
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirSourceLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"server/port":        "9000\n",
+		"logger.level":       "warn",
+		".hidden":            "ignored",
+		"cors/.swap/ignored": "ignored",
+	})
+
+	values, err := NewDirSource(dir).Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values["server.port"] != "9000" || values["logger.level"] != "warn" {
+		t.Fatalf("values = %v", values)
+	}
+}
+
+// TestSourcePrecedence 配置源覆盖配置文件，环境变量覆盖配置源
+func TestSourcePrecedence(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 8080\n  mode: debug\njwt:\n  secret: s\nlogger:\n  level: info\n")
+	src := NewDirSource(writeFiles(t, map[string]string{
+		"server/port":  "9000",
+		"logger/level": "warn",
+	}))
+	t.Setenv("EVAFRAME_LOGGER_LEVEL", "error")
+
+	opts := Options{Path: path, Sources: []Source{src}}
+	c, err := NewConfig(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Port != 9000 || c.Server.Mode != "debug" || c.Logger.Level != "error" {
+		t.Fatalf("port = %d, mode = %q, level = %q", c.Server.Port, c.Server.Mode, c.Logger.Level)
+	}
+
+	settings, err := Explain(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range settings {
+		if s.Key == "server.port" && (s.Origin != OriginSource || s.Location != src.Name()) {
+			t.Fatalf("server.port = %+v, want source %s", s, src.Name())
+		}
+	}
+}
+
+func TestOpenSource(t *testing.T) {
+	for _, raw := range []string{"dir:config/remote", "dir:///etc/evaframe"} {
+		if _, err := OpenSource(raw); err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"etcd://localhost:2379", "dir://host/path"} {
+		if _, err := OpenSource(raw); err == nil {
+			t.Fatalf("%s: want error", raw)
+		}
+	}
+}
+
+// TestWatchReloadsFromSource 配置源变化后与配置文件走同一套校验和替换流程
+func TestWatchReloadsFromSource(t *testing.T) {
+	path := writeConfig(t, "jwt:\n  secret: s\n")
+	remote := writeFiles(t, map[string]string{"logger/level": "info"})
+
+	h, err := Watch(Options{Path: path, Sources: []Source{NewDirSource(remote)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan string, 1)
+	OnChange(h, func(c *Config) string { return c.Logger.Level }, func(level string) { changed <- level })
+	rejected := make(chan error, 1)
+	h.OnError(func(err error) {
+		select {
+		case rejected <- err:
+		default:
+		}
+	})
+
+	// 等待配置源开始监听
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(remote, "logger", "level"), []byte("debug"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case level := <-changed:
+		if level != "debug" {
+			t.Fatalf("level = %q, want debug", level)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded after the source changed")
+	}
+
+	// 写入过程中可能读到空文件而被拒绝，清掉之前的错误
+	for len(rejected) > 0 {
+		<-rejected
+	}
+	if err := os.WriteFile(filepath.Join(remote, "logger", "level"), []byte("loud"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-rejected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalid value from the source was not rejected")
+	}
+	if h.Get().Logger.Level != "debug" {
+		t.Fatalf("level = %q, want the previous config to be kept", h.Get().Logger.Level)
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"evaframe/pkg/validator"
+
+	"github.com/spf13/viper"
+)
+
+// configValidator 使用 mapstructure 标签作为字段名，错误信息中的字段与配置文件一致
+var configValidator = validator.NewValidatorWithTagName("mapstructure")
+
+// ValidationError 汇总配置中所有未通过校验的字段
+type ValidationError struct {
+	Fields []*validator.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid config:")
+	for _, f := range e.Fields {
+		b.WriteString("\n  - ")
+		b.WriteString(f.Error())
+	}
+	return b.String()
+}
+
+// Validate 按字段上的 validate 标签校验配置，一次返回所有错误
+func Validate(c *Config) error {
+	fields, err := configValidator.Fields(c)
+	if err != nil {
+		return err
+	}
+	fields = append(fields, crossFieldErrors(c)...)
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// crossFieldErrors 校验标签无法表达的跨配置段约束
+func crossFieldErrors(c *Config) []*validator.FieldError {
+	var fields []*validator.FieldError
+	// 模拟 OIDC 提供方不校验任何凭据，可以任意邮箱登录，生产环境开启等于允许冒充任何用户
+	if c.OIDC.Mock.Enabled && c.Server.Mode == "release" {
+		fields = append(fields, &validator.FieldError{
+			Field: "oidc.mock.enabled",
+			Tag:   "excluded_if",
+			Param: "server.mode is release",
+			Value: true,
+		})
+	}
+	return fields
+}
+
+// setDefaults 按字段上的 default 标签设置默认值，优先级低于配置文件
+func setDefaults(v *viper.Viper) {
+	for _, l := range leaves(reflect.ValueOf(Config{}), "") {
+		if value, ok := l.field.Tag.Lookup("default"); ok {
+			v.SetDefault(l.key, value)
+		}
+	}
+}
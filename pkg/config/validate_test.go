@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultsAreApplied(t *testing.T) {
+	path := writeConfig(t, "jwt:\n  secret: s3cret\n")
+	c, err := NewConfig(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Port != 8080 || c.JWT.Algorithm != "HS256" || c.Auth.Mode != "jwt" || c.Lockout.MaxAttempts != 5 {
+		t.Fatalf("defaults not applied: port = %d, algorithm = %q, auth mode = %q, max attempts = %d",
+			c.Server.Port, c.JWT.Algorithm, c.Auth.Mode, c.Lockout.MaxAttempts)
+	}
+}
+
+// TestValidateReportsAllErrors 一次返回所有未通过校验的字段，而不是遇到第一个就停止
+func TestValidateReportsAllErrors(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 0\njwt:\n  algorithm: RS256\nsession:\n  same_site: loose\n")
+	_, err := NewConfig(Options{Path: path})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+	got := make(map[string]string)
+	for _, f := range verr.Fields {
+		got[f.Field] = f.Tag
+	}
+	want := map[string]string{
+		"server.port":       "min",
+		"jwt.keys":          "required_unless",
+		"session.same_site": "oneof",
+	}
+	for field, tag := range want {
+		if got[field] != tag {
+			t.Errorf("%s: tag = %q, want %q (all errors: %v)", field, got[field], tag, err)
+		}
+	}
+	if _, ok := got["jwt.secret"]; ok {
+		t.Error("jwt.secret is only required for HS256")
+	}
+}
+
+func TestValidateStructSliceElements(t *testing.T) {
+	var c Config
+	c.Server.Port = 8080
+	c.OIDC.Providers = []OIDCProvider{{Name: "google"}}
+
+	err := Validate(&c)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+	for _, f := range verr.Fields {
+		if f.Field == "oidc.providers[0].issuer" {
+			return
+		}
+	}
+	t.Fatalf("want an error for oidc.providers[0].issuer, got %v", err)
+}
+
+// TestValidateRejectsMockOIDCInRelease 模拟 OIDC 提供方可以冒充任何用户，release 模式下不能启用
+func TestValidateRejectsMockOIDCInRelease(t *testing.T) {
+	var c Config
+	c.Server.Port = 8080
+	c.Server.Mode = "release"
+	c.JWT.Algorithm = "HS256"
+	c.JWT.Secret = "s3cret"
+	c.OIDC.Mock.Enabled = true
+
+	err := Validate(&c)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+	found := false
+	for _, f := range verr.Fields {
+		found = found || f.Field == "oidc.mock.enabled"
+	}
+	if !found {
+		t.Fatalf("want an error for oidc.mock.enabled, got %v", err)
+	}
+
+	c.Server.Mode = "debug"
+	if err := Validate(&c); errors.As(err, &verr) {
+		for _, f := range verr.Fields {
+			if f.Field == "oidc.mock.enabled" {
+				t.Fatalf("mock OIDC must be allowed in debug mode: %v", err)
+			}
+		}
+	}
+}
+
+// TestValidateTrustedProxies 代理地址写错时拒绝启动，否则伪造的 X-Forwarded-For 会被当作客户端 IP
+func TestValidateTrustedProxies(t *testing.T) {
+	path := writeConfig(t, "jwt:\n  secret: s3cret\nserver:\n  trusted_proxies: [\"10.0.0.1\", \"172.16.0.0/12\", \"10.0.0.0/33\"]\n")
+	_, err := NewConfig(Options{Path: path})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "server.trusted_proxies[2]" {
+		t.Fatalf("err = %v, want a single error for server.trusted_proxies[2]", err)
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fileWatcher 监听参与合并的配置文件和 include 通配符所在的目录。文件被写入、重建，
+// 其符号链接指向变化（如 Kubernetes ConfigMap），或通配符匹配的文件增减时回调
+type fileWatcher struct {
+	watcher  *fsnotify.Watcher
+	onChange func(name string) *loaded
+
+	mu    sync.Mutex
+	files map[string]string // 文件绝对路径 → 符号链接解析后的真实路径
+	globs []string
+	dirs  map[string]bool
+}
+
+// watchFiles 开始监听 l 中的文件；onChange 返回非空结果时改为监听新结果中的文件
+func watchFiles(l *loaded, onChange func(name string) *loaded) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config files: %w", err)
+	}
+	fw := &fileWatcher{
+		watcher:  watcher,
+		onChange: onChange,
+		dirs:     make(map[string]bool),
+	}
+	if err := fw.track(l); err != nil {
+		watcher.Close()
+		return err
+	}
+	go fw.run()
+	return nil
+}
+
+// track 替换监听的文件列表。监听目录而不是文件本身，编辑器保存时常常先删除再重建文件
+func (fw *fileWatcher) track(l *loaded) error {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	fw.files = make(map[string]string, len(l.files))
+	for _, file := range l.files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		fw.files[abs] = realPath(abs)
+		if err := fw.watchDir(filepath.Dir(abs)); err != nil {
+			return err
+		}
+	}
+
+	fw.globs = fw.globs[:0]
+	for _, pattern := range l.globs {
+		abs, err := filepath.Abs(pattern)
+		if err != nil {
+			return err
+		}
+		fw.globs = append(fw.globs, abs)
+		// 通配符所在的目录可能还不存在，此时无法监听，不影响启动
+		_ = fw.watchDir(filepath.Dir(abs))
+	}
+	return nil
+}
+
+func (fw *fileWatcher) watchDir(dir string) error {
+	if fw.dirs[dir] {
+		return nil
+	}
+	if err := fw.watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	fw.dirs[dir] = true
+	return nil
+}
+
+func (fw *fileWatcher) run() {
+	for {
+		select {
+		case event, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			if name, changed := fw.changed(event); changed {
+				if l := fw.onChange(name); l != nil {
+					// 新的目录无法监听时保留已有的监听，下次修改时会再次尝试
+					_ = fw.track(l)
+				}
+			}
+		case _, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// changed 判断事件是否影响配置，返回受影响的文件
+func (fw *fileWatcher) changed(event fsnotify.Event) (string, bool) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	abs, err := filepath.Abs(event.Name)
+	if err != nil {
+		return "", false
+	}
+	written := event.Has(fsnotify.Write) || event.Has(fsnotify.Create)
+
+	if _, ok := fw.files[abs]; ok && written {
+		fw.files[abs] = realPath(abs)
+		return abs, true
+	}
+
+	// 通配符匹配的文件新增、修改或删除
+	for _, pattern := range fw.globs {
+		if ok, _ := filepath.Match(pattern, abs); ok {
+			return abs, true
+		}
+	}
+
+	// 目录中的符号链接被替换，文件路径不变但内容已变化
+	for file, real := range fw.files {
+		if filepath.Dir(file) != filepath.Dir(abs) {
+			continue
+		}
+		if current := realPath(file); current != real {
+			fw.files[file] = current
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// realPath 解析符号链接，失败时返回原路径
+func realPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
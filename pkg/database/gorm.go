@@ -2,21 +2,34 @@
 package database
 
 import (
+	"context"
 	"evaframe/pkg/config"
 	"evaframe/pkg/logger"
 	"fmt"
+	"time"
 
 	"github.com/glebarez/sqlite"
 	"github.com/google/wire"
+	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+const (
+	// maxPingBackoff 启动时重试连接的最长等待时间
+	maxPingBackoff = 30 * time.Second
+	// pingTimeout 单次连接检查的超时时间
+	pingTimeout = 5 * time.Second
+)
+
 var ProviderSet = wire.NewSet(NewDB)
 
-// NewDB GORM 数据库实例 Provider
-func NewDB(cfg *config.Config, zapLogger *logger.Logger) (*gorm.DB, error) {
+// sleep 重试前的等待，测试中替换以免真实等待
+var sleep = time.Sleep
+
+// NewDB GORM 数据库实例 Provider，按配置设置连接池并确认数据库可以连接，返回的 cleanup 关闭连接池
+func NewDB(cfg *config.Config, zapLogger *logger.Logger) (*gorm.DB, func(), error) {
 	var dialector gorm.Dialector
 
 	switch cfg.Database.Type {
@@ -27,17 +40,97 @@ func NewDB(cfg *config.Config, zapLogger *logger.Logger) (*gorm.DB, error) {
 	case "postgres":
 		dialector = postgres.Open(cfg.Database.DSN)
 	default:
-		return nil, fmt.Errorf("不支持的数据库类型: %s", cfg.Database.Type)
+		return nil, nil, fmt.Errorf("不支持的数据库类型: %s", cfg.Database.Type)
 	}
 
 	gcfg := &gorm.Config{
 		// 自定义日志器
-		Logger: logger.NewGormLogger(zapLogger.Logger),
+		Logger: logger.NewGormLogger(zapLogger.Logger, cfg.Database.SlowThreshold),
+		// 由 ping 负责重试，数据库晚于应用启动时不会直接失败
+		DisableAutomaticPing: true,
 	}
 
 	db, err := gorm.Open(dialector, gcfg)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		if err := sqlDB.Close(); err != nil {
+			zapLogger.Error("failed to close database", zap.Error(err))
+		}
+	}
+
+	ConfigurePool(db, PoolFromConfig(cfg))
+	if err := ping(db, cfg.Database.PingRetries, cfg.Database.PingBackoff, zapLogger); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return db, cleanup, nil
+}
+
+// ping 检查数据库连接，失败时按指数退避重试 retries 次
+func ping(db *gorm.DB, retries int, backoff time.Duration, zapLogger *logger.Logger) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err = sqlDB.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= retries {
+			return fmt.Errorf("failed to connect database after %d attempts: %w", attempt+1, err)
+		}
+
+		zapLogger.Warn("database is not ready, retrying",
+			zap.Int("attempt", attempt+1), zap.Duration("backoff", backoff), zap.Error(err))
+		sleep(backoff)
+		backoff = min(backoff*2, maxPingBackoff)
+	}
+}
+
+// Pool 连接池设置
+type Pool struct {
+	MaxOpen         int
+	MaxIdle         int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// PoolFromConfig 返回配置中的连接池设置
+func PoolFromConfig(cfg *config.Config) Pool {
+	return Pool{
+		MaxOpen:         cfg.Database.MaxOpen,
+		MaxIdle:         cfg.Database.MaxIdle,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+	}
+}
+
+// ConfigurePool 设置连接池，可以在运行时调用
+func ConfigurePool(db *gorm.DB, pool Pool) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+	sqlDB.SetMaxOpenConns(pool.MaxOpen)
+	sqlDB.SetMaxIdleConns(pool.MaxIdle)
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+}
+
+// SetSlowThreshold 在运行时调整慢查询阈值，仅对使用 logger.GormLogger 的实例生效
+func SetSlowThreshold(db *gorm.DB, threshold time.Duration) {
+	if l, ok := db.Logger.(logger.GormLogger); ok {
+		l.SetSlowThreshold(threshold)
 	}
-	return db, nil
 }
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"evaframe/pkg/config"
+	"evaframe/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newTestLogger() *logger.Logger {
+	return &logger.Logger{Logger: zap.NewNop()}
+}
+
+func sqliteConfig(dsn string) *config.Config {
+	var cfg config.Config
+	cfg.Database.Type = "sqlite"
+	cfg.Database.DSN = dsn
+	return &cfg
+}
+
+// fakeSleep 记录每次等待的时长，第 ready 次等待时调用 onReady
+func fakeSleep(t *testing.T, ready int, onReady func()) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	sleep = func(d time.Duration) {
+		waits = append(waits, d)
+		if len(waits) == ready && onReady != nil {
+			onReady()
+		}
+	}
+	t.Cleanup(func() { sleep = time.Sleep })
+	return &waits
+}
+
+func TestNewDBConfiguresPoolAndCleanup(t *testing.T) {
+	cfg := sqliteConfig(filepath.Join(t.TempDir(), "app.db"))
+	cfg.Database.MaxOpen = 3
+
+	db, cleanup, err := NewDB(cfg, newTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := sqlDB.Stats(); stats.MaxOpenConnections != 3 {
+		t.Fatalf("MaxOpenConnections = %d, want 3", stats.MaxOpenConnections)
+	}
+
+	cleanup()
+	if err := sqlDB.Ping(); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Fatalf("ping after cleanup: err = %v, want database is closed", err)
+	}
+}
+
+func TestConfigurePoolLimitsIdleConnections(t *testing.T) {
+	db, cleanup, err := NewDB(sqliteConfig(filepath.Join(t.TempDir(), "app.db")), newTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	ConfigurePool(db, Pool{MaxOpen: 3, MaxIdle: 1, ConnMaxLifetime: time.Hour})
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var conns []interface{ Close() error }
+	for range 3 {
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+	if stats := sqlDB.Stats(); stats.MaxOpenConnections != 3 || stats.InUse != 3 {
+		t.Fatalf("stats = %+v, want 3 connections in use with a limit of 3", stats)
+	}
+	closed := sqlDB.Stats().MaxIdleClosed
+	for _, conn := range conns {
+		conn.Close()
+	}
+	if stats := sqlDB.Stats(); stats.Idle != 1 || stats.MaxIdleClosed-closed != 2 {
+		t.Fatalf("stats = %+v, want 1 idle connection and 2 closed by the idle limit", stats)
+	}
+}
+
+// newUnreachableDB 返回数据库文件所在目录已被删除的连接，重新创建目录前无法建立新连接
+func newUnreachableDB(t *testing.T) (*gorm.DB, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	db, cleanup, err := NewDB(sqliteConfig(filepath.Join(dir, "app.db")), newTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cleanup)
+
+	// 不保留空闲连接，每次 ping 都重新打开数据库文件
+	ConfigurePool(db, Pool{})
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	return db, dir
+}
+
+// TestPingRetriesWithBackoff 数据库暂时不可用时按指数退避重试，退避时长不超过上限
+func TestPingRetriesWithBackoff(t *testing.T) {
+	db, dir := newUnreachableDB(t)
+	waits := fakeSleep(t, 3, func() {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err := ping(db, 5, 10*time.Second, newTestLogger()); err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, maxPingBackoff}
+	if !slices.Equal(*waits, want) {
+		t.Fatalf("waits = %v, want %v", *waits, want)
+	}
+}
+
+func TestPingGivesUpAfterRetries(t *testing.T) {
+	db, _ := newUnreachableDB(t)
+	waits := fakeSleep(t, 0, nil)
+
+	err := ping(db, 2, time.Second, newTestLogger())
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("err = %v, want failure after 3 attempts", err)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(*waits, want) {
+		t.Fatalf("waits = %v, want %v", *waits, want)
+	}
+}
+
+func TestNewDBRejectsUnknownType(t *testing.T) {
+	cfg := sqliteConfig("")
+	cfg.Database.Type = "oracle"
+	if _, _, err := NewDB(cfg, newTestLogger()); err == nil {
+		t.Fatal("unknown database type accepted")
+	}
+}
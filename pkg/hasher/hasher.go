@@ -0,0 +1,110 @@
+// Package hasher 提供可插拔的密码哈希实现
+//
+// 所有实现生成的哈希串都是自描述的（带算法标识和参数），
+// 因此同一张用户表中可以同时存在多种算法生成的哈希，
+// 校验时根据哈希串自动选择对应的算法。
+package hasher
+
+import (
+	"errors"
+	"fmt"
+
+	"evaframe/pkg/config"
+
+	"github.com/google/wire"
+)
+
+var ProviderSet = wire.NewSet(NewPasswordHasher)
+
+// ErrUnknownHash 无法识别的哈希格式
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// PasswordHasher 密码哈希接口
+type PasswordHasher interface {
+	// Hash 生成密码的哈希串
+	Hash(password string) (string, error)
+	// Verify 校验明文密码与哈希串是否匹配
+	Verify(encoded, password string) (bool, error)
+	// NeedsRehash 判断哈希串是否需要用当前算法/参数重新生成
+	NeedsRehash(encoded string) bool
+}
+
+// Algorithm 单一哈希算法，在 PasswordHasher 的基础上可以识别自己生成的哈希串
+type Algorithm interface {
+	PasswordHasher
+	// Match 判断哈希串是否由该算法生成
+	Match(encoded string) bool
+}
+
+// NewPasswordHasher 根据配置创建密码哈希器 Provider
+//
+// 新密码使用配置中选定的算法，校验时兼容 bcrypt、argon2id 以及历史遗留的 MD5 哈希。
+func NewPasswordHasher(cfg *config.Config) (PasswordHasher, error) {
+	bcryptHasher := NewBcryptHasher(cfg.Password.BcryptCost)
+	argon2Hasher := NewArgon2idHasher(Argon2Params{
+		Memory:      cfg.Password.Argon2.Memory,
+		Iterations:  cfg.Password.Argon2.Iterations,
+		Parallelism: cfg.Password.Argon2.Parallelism,
+		SaltLength:  cfg.Password.Argon2.SaltLength,
+		KeyLength:   cfg.Password.Argon2.KeyLength,
+	})
+
+	var preferred Algorithm
+	switch cfg.Password.Algorithm {
+	case "", "bcrypt":
+		preferred = bcryptHasher
+	case "argon2id":
+		preferred = argon2Hasher
+	default:
+		return nil, fmt.Errorf("不支持的密码哈希算法: %s", cfg.Password.Algorithm)
+	}
+
+	return NewMultiHasher(preferred, bcryptHasher, argon2Hasher, NewMD5Hasher()), nil
+}
+
+// MultiHasher 使用首选算法生成哈希，并能校验多种算法生成的哈希
+type MultiHasher struct {
+	preferred  Algorithm
+	algorithms []Algorithm
+}
+
+// NewMultiHasher 创建 MultiHasher，preferred 用于生成新哈希，algorithms 用于校验
+func NewMultiHasher(preferred Algorithm, algorithms ...Algorithm) *MultiHasher {
+	return &MultiHasher{
+		preferred:  preferred,
+		algorithms: algorithms,
+	}
+}
+
+func (m *MultiHasher) Hash(password string) (string, error) {
+	return m.preferred.Hash(password)
+}
+
+func (m *MultiHasher) Verify(encoded, password string) (bool, error) {
+	alg := m.find(encoded)
+	if alg == nil {
+		return false, ErrUnknownHash
+	}
+	return alg.Verify(encoded, password)
+}
+
+func (m *MultiHasher) NeedsRehash(encoded string) bool {
+	// 非首选算法生成的哈希一律需要升级
+	if !m.preferred.Match(encoded) {
+		return true
+	}
+	// 首选算法但参数已变化
+	return m.preferred.NeedsRehash(encoded)
+}
+
+func (m *MultiHasher) find(encoded string) Algorithm {
+	if m.preferred.Match(encoded) {
+		return m.preferred
+	}
+	for _, alg := range m.algorithms {
+		if alg.Match(encoded) {
+			return alg
+		}
+	}
+	return nil
+}
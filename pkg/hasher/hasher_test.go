@@ -0,0 +1,172 @@
+package hasher
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"evaframe/pkg/config"
+)
+
+// testArgon2Params 测试用的低开销参数
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func md5Hex(password string) string {
+	sum := md5.Sum([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func mustHash(t *testing.T, h PasswordHasher, password string) string {
+	t.Helper()
+	encoded, err := h.Hash(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestMultiHasherVerify(t *testing.T) {
+	bcryptHasher := NewBcryptHasher(4)
+	argon2Hasher := NewArgon2idHasher(testArgon2Params)
+	m := NewMultiHasher(bcryptHasher, bcryptHasher, argon2Hasher, NewMD5Hasher())
+
+	bcryptHash := mustHash(t, bcryptHasher, "secret")
+	argon2Hash := mustHash(t, argon2Hasher, "secret")
+
+	tests := []struct {
+		name     string
+		encoded  string
+		password string
+		want     bool
+		wantErr  bool
+	}{
+		{"bcrypt", bcryptHash, "secret", true, false},
+		{"bcrypt wrong password", bcryptHash, "wrong", false, false},
+		{"bcrypt malformed", "$2a$04$tooshort", "secret", false, true},
+		{"argon2id", argon2Hash, "secret", true, false},
+		{"argon2id wrong password", argon2Hash, "wrong", false, false},
+		{"argon2id missing fields", "$argon2id$v=19$m=1024,t=1,p=1", "secret", false, true},
+		{"argon2id bad version", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", "secret", false, true},
+		{"argon2id bad params", "$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5", "secret", false, true},
+		{"argon2id bad salt", "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5", "secret", false, true},
+		{"md5", md5Hex("secret"), "secret", true, false},
+		{"md5 wrong password", md5Hex("secret"), "wrong", false, false},
+		{"md5 not hex", "zz" + md5Hex("secret")[2:], "secret", false, true},
+		{"unknown format", "secret", "secret", false, true},
+		{"empty", "", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := m.Verify(tt.encoded, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.want {
+				t.Fatalf("ok = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.Verify("secret", "secret"); !errors.Is(err, ErrUnknownHash) {
+		t.Fatalf("err = %v, want ErrUnknownHash", err)
+	}
+}
+
+func TestMultiHasherNeedsRehash(t *testing.T) {
+	bcryptHasher := NewBcryptHasher(4)
+	argon2Hasher := NewArgon2idHasher(testArgon2Params)
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+
+	tests := []struct {
+		name      string
+		preferred Algorithm
+		encoded   string
+		want      bool
+	}{
+		{"bcrypt preferred, same cost", bcryptHasher, mustHash(t, bcryptHasher, "secret"), false},
+		{"bcrypt preferred, cost changed", bcryptHasher, mustHash(t, NewBcryptHasher(5), "secret"), true},
+		{"bcrypt preferred, argon2id hash", bcryptHasher, mustHash(t, argon2Hasher, "secret"), true},
+		{"bcrypt preferred, md5 hash", bcryptHasher, md5Hex("secret"), true},
+		{"argon2id preferred, same params", argon2Hasher, mustHash(t, argon2Hasher, "secret"), false},
+		{"argon2id preferred, params changed", argon2Hasher, mustHash(t, NewArgon2idHasher(stronger), "secret"), true},
+		{"argon2id preferred, bcrypt hash", argon2Hasher, mustHash(t, bcryptHasher, "secret"), true},
+		{"argon2id preferred, malformed", argon2Hasher, "$argon2id$v=19$broken", true},
+		{"unknown format", bcryptHasher, "secret", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiHasher(tt.preferred, bcryptHasher, argon2Hasher, NewMD5Hasher())
+			if got := m.NeedsRehash(tt.encoded); got != tt.want {
+				t.Fatalf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUpgradeFromMD5 登录时校验旧的 MD5 哈希，再用首选算法重新生成
+func TestUpgradeFromMD5(t *testing.T) {
+	for _, preferred := range []Algorithm{NewBcryptHasher(4), NewArgon2idHasher(testArgon2Params)} {
+		m := NewMultiHasher(preferred, NewBcryptHasher(4), NewArgon2idHasher(testArgon2Params), NewMD5Hasher())
+		legacy := md5Hex("secret")
+
+		ok, err := m.Verify(legacy, "secret")
+		if err != nil || !ok {
+			t.Fatalf("verify legacy hash: ok = %v, err = %v", ok, err)
+		}
+		if !m.NeedsRehash(legacy) {
+			t.Fatal("legacy hash does not need rehash")
+		}
+
+		upgraded := mustHash(t, m, "secret")
+		if !preferred.Match(upgraded) {
+			t.Fatalf("upgraded hash %q not generated by the preferred algorithm", upgraded)
+		}
+		if m.NeedsRehash(upgraded) {
+			t.Fatal("upgraded hash still needs rehash")
+		}
+		if ok, err := m.Verify(upgraded, "secret"); err != nil || !ok {
+			t.Fatalf("verify upgraded hash: ok = %v, err = %v", ok, err)
+		}
+	}
+}
+
+func TestMD5DoesNotHash(t *testing.T) {
+	if _, err := NewMD5Hasher().Hash("secret"); err == nil {
+		t.Fatal("md5 generated a new hash")
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		want      Algorithm
+		wantErr   bool
+	}{
+		{"", &BcryptHasher{}, false},
+		{"bcrypt", &BcryptHasher{}, false},
+		{"argon2id", &Argon2idHasher{}, false},
+		{"md5", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			var cfg config.Config
+			cfg.Password.Algorithm = tt.algorithm
+			cfg.Password.BcryptCost = 4
+			cfg.Password.Argon2.Memory = testArgon2Params.Memory
+			cfg.Password.Argon2.Iterations = testArgon2Params.Iterations
+
+			h, err := NewPasswordHasher(&cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !tt.want.Match(mustHash(t, h, "secret")) {
+				t.Fatalf("new hashes not generated with %q", tt.algorithm)
+			}
+		})
+	}
+}
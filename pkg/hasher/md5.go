@@ -0,0 +1,37 @@
+package hasher
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+)
+
+// MD5Hasher 历史遗留的无盐 MD5 哈希，仅用于校验旧数据，不再用于生成新哈希
+type MD5Hasher struct{}
+
+// NewMD5Hasher 创建 MD5 哈希器
+func NewMD5Hasher() *MD5Hasher {
+	return &MD5Hasher{}
+}
+
+func (h *MD5Hasher) Hash(password string) (string, error) {
+	return "", errors.New("md5 is only supported for verifying legacy hashes")
+}
+
+func (h *MD5Hasher) Verify(encoded, password string) (bool, error) {
+	sum := md5.Sum([]byte(password))
+	return subtle.ConstantTimeCompare([]byte(encoded), []byte(hex.EncodeToString(sum[:]))) == 1, nil
+}
+
+func (h *MD5Hasher) NeedsRehash(encoded string) bool {
+	return true
+}
+
+func (h *MD5Hasher) Match(encoded string) bool {
+	if len(encoded) != md5.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(encoded)
+	return err == nil
+}
@@ -2,9 +2,12 @@ package jwt
 
 import (
 	"errors"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"evaframe/pkg/config"
+	"evaframe/pkg/helpers"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/wire"
@@ -12,41 +15,307 @@ import (
 
 var ProviderSet = wire.NewSet(NewJWT)
 
+const (
+	// DefaultAccessTTL 默认访问令牌有效期
+	DefaultAccessTTL = 15 * time.Minute
+	// DefaultRefreshTTL 默认刷新令牌有效期
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// 非访问令牌的用途
+const (
+	// PurposeMFAPending 已通过密码验证、等待第二因素验证的临时令牌
+	PurposeMFAPending = "mfa_pending"
+	// PurposeVerifyEmail 邮箱验证链接中的令牌
+	PurposeVerifyEmail = "verify_email"
+	// PurposeResetPassword 重置密码链接中的令牌
+	PurposeResetPassword = "reset_password"
+)
+
+var (
+	// ErrNoSigningKey 当前时间没有可用于签名的密钥
+	ErrNoSigningKey = errors.New("no active signing key")
+	// ErrUnknownKey 令牌头中的 kid 不存在、尚未生效或已过期
+	ErrUnknownKey = errors.New("unknown signing key")
+)
+
+// JWT 签发和校验令牌，配置热更新时通过 Reload 整体替换签名设置
 type JWT struct {
-	secret string
+	current atomic.Pointer[settings]
+}
+
+// settings 一次配置对应的签名设置，创建后不再修改
+type settings struct {
+	method     jwt.SigningMethod
+	secret     string
+	keys       []*SigningKey // 非对称算法的密钥，按 NotBefore 升序
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID      uint     `json:"user_id"`
+	Email       string   `json:"email"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Purpose 非访问令牌的用途，如 mfa_pending；访问令牌为空
+	Purpose string `json:"purpose,omitempty"`
+	// ClientID 通过 OAuth2 授权签发时的客户端，Scope 为授予的范围（空格分隔，RFC 9068）
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWT(cfg *config.Config) *JWT {
-	return &JWT{
-		secret: cfg.JWT.Secret,
+// Identity 令牌主体信息，客户端凭据模式签发的令牌没有用户，UserID 为 0
+type Identity struct {
+	UserID      uint
+	Email       string
+	Roles       []string
+	Permissions []string
+	ClientID    string
+	Scope       string
+}
+
+func NewJWT(cfg *config.Config) (*JWT, error) {
+	s, err := newSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &JWT{}
+	j.current.Store(s)
+	return j, nil
+}
+
+// Reload 使用新配置替换签名算法、密钥、签发者和有效期，新配置无效时保持原设置不变
+//
+// 更换算法或密钥后，用旧设置签发的令牌将无法通过校验
+func (j *JWT) Reload(cfg *config.Config) error {
+	s, err := newSettings(cfg)
+	if err != nil {
+		return err
+	}
+	j.current.Store(s)
+	return nil
+}
+
+// Validate 检查配置能否用于签发令牌（算法、密钥文件等）
+func Validate(cfg *config.Config) error {
+	_, err := newSettings(cfg)
+	return err
+}
+
+func newSettings(cfg *config.Config) (*settings, error) {
+	method, err := signingMethod(cfg.JWT.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*SigningKey
+	if method != jwt.SigningMethodHS256 {
+		if keys, err = loadSigningKeys(cfg, method.Alg()); err != nil {
+			return nil, err
+		}
+	}
+
+	accessTTL := cfg.JWT.AccessTTL
+	if accessTTL == 0 {
+		accessTTL = DefaultAccessTTL
+	}
+	refreshTTL := cfg.JWT.RefreshTTL
+	if refreshTTL == 0 {
+		refreshTTL = DefaultRefreshTTL
+	}
+
+	return &settings{
+		method:     method,
+		secret:     cfg.JWT.Secret,
+		keys:       keys,
+		issuer:     cfg.JWT.Issuer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}, nil
+}
+
+// AccessTTL 返回访问令牌有效期
+func (j *JWT) AccessTTL() time.Duration {
+	return j.current.Load().accessTTL
+}
+
+// RefreshTTL 返回刷新令牌有效期
+func (j *JWT) RefreshTTL() time.Duration {
+	return j.current.Load().refreshTTL
+}
+
+func (j *JWT) GenerateToken(identity Identity) (string, error) {
+	_, token, err := j.IssueToken(identity)
+	return token, err
+}
+
+// IssueToken 签发访问令牌，同时返回其声明（jti、过期时间等）
+func (j *JWT) IssueToken(identity Identity) (*Claims, string, error) {
+	// jti 唯一标识一个令牌，用于吊销
+	jti, err := helpers.RandomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// sub 为用户 ID，客户端凭据模式下为客户端 ID
+	subject := strconv.FormatUint(uint64(identity.UserID), 10)
+	if identity.UserID == 0 {
+		subject = identity.ClientID
 	}
+
+	s := j.current.Load()
+	now := time.Now()
+	claims := &Claims{
+		UserID:      identity.UserID,
+		Email:       identity.Email,
+		Roles:       identity.Roles,
+		Permissions: identity.Permissions,
+		ClientID:    identity.ClientID,
+		Scope:       identity.Scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   subject,
+			Issuer:    s.issuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return nil, "", err
+	}
+	return claims, token, nil
 }
 
-func (j *JWT) GenerateToken(userID uint, email string) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		Email:  email,
+// GeneratePurposeToken 生成指定用途的短期令牌，这类令牌不能当作访问令牌使用
+func (j *JWT) GeneratePurposeToken(userID uint, email, purpose string, ttl time.Duration) (*Claims, string, error) {
+	jti, err := helpers.RandomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s := j.current.Load()
+	now := time.Now()
+	claims := &Claims{
+		UserID:  userID,
+		Email:   email,
+		Purpose: purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			Issuer:    s.issuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secret))
+	token, err := s.sign(claims)
+	if err != nil {
+		return nil, "", err
+	}
+	return claims, token, nil
+}
+
+// ParsePurposeToken 解析并校验指定用途的令牌
+func (j *JWT) ParsePurposeToken(tokenString, purpose string) (*Claims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, errors.New("invalid token purpose")
+	}
+	return claims, nil
+}
+
+// sign 使用当前签名密钥签名，非对称算法会在令牌头中写入 kid
+func (s *settings) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.method, claims)
+	if s.method == jwt.SigningMethodHS256 {
+		return token.SignedString([]byte(s.secret))
+	}
+
+	key := s.currentKey(time.Now())
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.PrivateKey)
+}
+
+// currentKey 返回 t 时刻用于签名的密钥：已生效且未过期的密钥中 NotBefore 最晚的一个
+func (s *settings) currentKey(t time.Time) *SigningKey {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		key := s.keys[i]
+		if !key.NotBefore.After(t) && key.activeAt(t) {
+			return key
+		}
+	}
+	return nil
 }
 
+// verificationKey 令牌验签回调，按 kid 查找已生效且未过期的公钥；
+// 预发布的密钥尚未用于签名，用它签发的令牌一律拒绝
+func (s *settings) verificationKey(token *jwt.Token) (any, error) {
+	if s.method == jwt.SigningMethodHS256 {
+		return []byte(s.secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	now := time.Now()
+	for _, key := range s.keys {
+		if key.KID == kid && !key.NotBefore.After(now) && key.activeAt(now) {
+			return key.PrivateKey.Public(), nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// JWKS 返回当前发布的公钥集合，包括尚未生效的预发布密钥；HS256 返回空集合
+func (j *JWT) JWKS() (*JWKS, error) {
+	s := j.current.Load()
+	set := &JWKS{Keys: []JWK{}}
+	now := time.Now()
+	for _, key := range s.keys {
+		if !key.activeAt(now) {
+			continue
+		}
+		jwk, err := PublicJWK(key.KID, s.method.Alg(), key.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// ParseToken 解析访问令牌，拒绝带有用途的临时令牌
 func (j *JWT) ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
-		return []byte(j.secret), nil
-	})
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, errors.New("not an access token")
+	}
+	return claims, nil
+}
+
+func (j *JWT) parse(tokenString string) (*Claims, error) {
+	// 只接受配置的算法，防止算法混淆攻击
+	s := j.current.Load()
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{s.method.Alg()})}
+	if s.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.verificationKey, opts...)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,243 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"evaframe/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey 一个非对称签名密钥及其轮换时间表
+//
+// 密钥在 NotBefore 之前只发布到 JWKS，既不用于签名也不用于验签（预发布，方便其他服务提前缓存），
+// NotBefore 之后成为签名候选，多个候选中 NotBefore 最晚的那个用于签名；
+// ExpiresAt 之后既不再发布也不再用于验签。
+type SigningKey struct {
+	KID        string
+	PrivateKey crypto.Signer
+	NotBefore  time.Time
+	ExpiresAt  time.Time
+}
+
+// activeAt 判断密钥在 t 时刻是否可用于验签
+func (k *SigningKey) activeAt(t time.Time) bool {
+	return k.ExpiresAt.IsZero() || t.Before(k.ExpiresAt)
+}
+
+// JWK JSON Web Key（RFC 7517），只包含公钥部分
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// signingMethod 根据算法名返回签名方法
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case "", "HS256":
+		return jwt.SigningMethodHS256, nil
+	case "RS256":
+		return jwt.SigningMethodRS256, nil
+	case "ES256":
+		return jwt.SigningMethodES256, nil
+	case "EdDSA":
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("不支持的 JWT 签名算法: %s", alg)
+	}
+}
+
+// loadSigningKeys 从配置加载并校验签名密钥，按 NotBefore 升序排列
+func loadSigningKeys(cfg *config.Config, alg string) ([]*SigningKey, error) {
+	keys := make([]*SigningKey, 0, len(cfg.JWT.Keys))
+	seen := make(map[string]bool)
+
+	for _, kc := range cfg.JWT.Keys {
+		if kc.KID == "" {
+			return nil, errors.New("jwt key: kid is required")
+		}
+		if seen[kc.KID] {
+			return nil, fmt.Errorf("jwt key %s: duplicate kid", kc.KID)
+		}
+		seen[kc.KID] = true
+
+		pemData := []byte(kc.PrivateKey)
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("jwt key %s: %w", kc.KID, err)
+			}
+			pemData = data
+		}
+
+		signer, err := ParsePrivateKeyPEM(pemData)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", kc.KID, err)
+		}
+		if err := checkKeyAlgorithm(signer, alg); err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", kc.KID, err)
+		}
+
+		key := &SigningKey{KID: kc.KID, PrivateKey: signer}
+		if kc.NotBefore != "" {
+			if key.NotBefore, err = time.Parse(time.RFC3339, kc.NotBefore); err != nil {
+				return nil, fmt.Errorf("jwt key %s: invalid not_before: %w", kc.KID, err)
+			}
+		}
+		if kc.ExpiresAt != "" {
+			if key.ExpiresAt, err = time.Parse(time.RFC3339, kc.ExpiresAt); err != nil {
+				return nil, fmt.Errorf("jwt key %s: invalid expires_at: %w", kc.KID, err)
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwt algorithm %s requires at least one key", alg)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].NotBefore.Before(keys[j].NotBefore)
+	})
+	return keys, nil
+}
+
+// ParsePrivateKeyPEM 解析 PEM 编码的私钥，支持 PKCS#8、PKCS#1 与 SEC 1 格式
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, errors.New("unsupported private key type")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key format")
+}
+
+// checkKeyAlgorithm 确认密钥类型与签名算法匹配
+func checkKeyAlgorithm(key crypto.Signer, alg string) error {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if alg == "RS256" {
+			return nil
+		}
+	case *ecdsa.PrivateKey:
+		if alg == "ES256" && k.Curve == elliptic.P256() {
+			return nil
+		}
+	case ed25519.PrivateKey:
+		if alg == "EdDSA" {
+			return nil
+		}
+	}
+	return fmt.Errorf("key type %T does not match algorithm %s", key, alg)
+}
+
+// PublicJWK 将签名密钥的公钥部分编码为 JWK
+func PublicJWK(kid, alg string, key crypto.Signer) (JWK, error) {
+	enc := base64.RawURLEncoding
+	jwk := JWK{Kid: kid, Use: "sig", Alg: alg}
+
+	switch pub := key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc.EncodeToString(pub.N.Bytes())
+		jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = enc.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = enc.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc.EncodeToString(pub)
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return jwk, nil
+}
+
+// PublicKey 将 JWK 解码为公钥，用于验证其他签发方（如 OIDC 提供方）的令牌
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	enc := base64.RawURLEncoding
+
+	switch k.Kty {
+	case "RSA":
+		n, err := enc.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: invalid n: %w", k.Kid, err)
+		}
+		e, err := enc.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwk %s: invalid e", k.Kid)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("jwk %s: unsupported curve %s", k.Kid, k.Crv)
+		}
+		x, errX := enc.DecodeString(k.X)
+		y, errY := enc.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("jwk %s: invalid coordinates", k.Kid)
+		}
+		// 借助 ecdh 校验点在曲线上，防止无效曲线攻击
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("jwk %s: %w", k.Kid, err)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("jwk %s: unsupported curve %s", k.Kid, k.Crv)
+		}
+		x, err := enc.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: invalid x", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("jwk %s: unsupported key type %s", k.Kid, k.Kty)
+	}
+}
@@ -0,0 +1,317 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"evaframe/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var keyTypes = []struct {
+	alg      string
+	generate func(t *testing.T) crypto.Signer
+}{
+	{"RS256", func(t *testing.T) crypto.Signer {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}},
+	{"ES256", func(t *testing.T) crypto.Signer {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}},
+	{"EdDSA", func(t *testing.T) crypto.Signer {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}},
+}
+
+func pkcs8PEM(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// rotation 一次密钥轮换中的四个密钥：已过期、旧的、当前的和预发布的
+type rotation struct {
+	expired, previous, current, next crypto.Signer
+	cfg                              *config.Config
+}
+
+func newRotation(t *testing.T, alg string, generate func(t *testing.T) crypto.Signer) *rotation {
+	t.Helper()
+	now := time.Now()
+	r := &rotation{expired: generate(t), previous: generate(t), current: generate(t), next: generate(t)}
+	r.cfg = &config.Config{}
+	r.cfg.JWT.Algorithm = alg
+	// 故意打乱顺序，加载时按 not_before 排序
+	r.cfg.JWT.Keys = []config.JWTKey{
+		{KID: "next", PrivateKey: pkcs8PEM(t, r.next), NotBefore: now.Add(time.Hour).Format(time.RFC3339)},
+		{KID: "current", PrivateKey: pkcs8PEM(t, r.current), NotBefore: now.Add(-time.Hour).Format(time.RFC3339)},
+		{KID: "expired", PrivateKey: pkcs8PEM(t, r.expired), ExpiresAt: now.Add(-time.Minute).Format(time.RFC3339)},
+		{KID: "previous", PrivateKey: pkcs8PEM(t, r.previous), NotBefore: now.Add(-24 * time.Hour).Format(time.RFC3339)},
+	}
+	return r
+}
+
+func newTestJWT(t *testing.T, cfg *config.Config) *JWT {
+	t.Helper()
+	j, err := NewJWT(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+// signWith 用指定密钥和 kid 签发一个访问令牌
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, &Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestSigningKeySelection(t *testing.T) {
+	for _, kt := range keyTypes {
+		t.Run(kt.alg, func(t *testing.T) {
+			r := newRotation(t, kt.alg, kt.generate)
+			j := newTestJWT(t, r.cfg)
+			s := j.current.Load()
+
+			now := time.Now()
+			tests := []struct {
+				at   time.Time
+				want string
+			}{
+				{now.Add(-48 * time.Hour), "expired"},
+				{now.Add(-2 * time.Hour), "previous"},
+				{now, "current"},
+				{now.Add(2 * time.Hour), "next"},
+			}
+			for _, tt := range tests {
+				if key := s.currentKey(tt.at); key == nil || key.KID != tt.want {
+					t.Fatalf("currentKey(%s) = %v, want %s", tt.at, key, tt.want)
+				}
+			}
+
+			token, err := j.GenerateToken(Identity{UserID: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Header["kid"] != "current" || parsed.Header["alg"] != kt.alg {
+				t.Fatalf("header = %v, want kid current and alg %s", parsed.Header, kt.alg)
+			}
+			if _, err := j.ParseToken(token); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestNoSigningKey(t *testing.T) {
+	key := keyTypes[1].generate(t)
+	cfg := &config.Config{}
+	cfg.JWT.Algorithm = "ES256"
+	cfg.JWT.Keys = []config.JWTKey{{KID: "future", PrivateKey: pkcs8PEM(t, key), NotBefore: time.Now().Add(time.Hour).Format(time.RFC3339)}}
+
+	if _, err := newTestJWT(t, cfg).GenerateToken(Identity{UserID: 1}); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("err = %v, want ErrNoSigningKey", err)
+	}
+}
+
+// TestVerificationKeyByKID 按 kid 选择验签公钥，轮换后旧密钥签发的令牌在过期前仍然有效，
+// 预发布的密钥在生效前不接受
+func TestVerificationKeyByKID(t *testing.T) {
+	for _, kt := range keyTypes {
+		t.Run(kt.alg, func(t *testing.T) {
+			r := newRotation(t, kt.alg, kt.generate)
+			j := newTestJWT(t, r.cfg)
+			method := j.current.Load().method
+
+			tests := []struct {
+				name    string
+				kid     string
+				key     crypto.Signer
+				wantErr error
+			}{
+				{"previous key", "previous", r.previous, nil},
+				{"current key", "current", r.current, nil},
+				{"pre-published key", "next", r.next, ErrUnknownKey},
+				{"expired key", "expired", r.expired, ErrUnknownKey},
+				{"unknown kid", "nope", r.current, ErrUnknownKey},
+				{"missing kid", "", r.current, ErrUnknownKey},
+				{"kid of another key", "previous", r.current, jwt.ErrTokenSignatureInvalid},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					_, err := j.ParseToken(signWith(t, method, tt.kid, tt.key))
+					if tt.wantErr == nil && err != nil {
+						t.Fatal(err)
+					}
+					if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+						t.Fatalf("err = %v, want %v", err, tt.wantErr)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestRejectsOtherAlgorithm 只接受配置的算法，包括用公钥充当 HMAC 密钥的算法混淆攻击
+func TestRejectsOtherAlgorithm(t *testing.T) {
+	rsaKey := keyTypes[0].generate(t)
+	ecKey := keyTypes[1].generate(t)
+	edKey := keyTypes[2].generate(t)
+
+	cfg := &config.Config{}
+	cfg.JWT.Algorithm = "RS256"
+	cfg.JWT.Keys = []config.JWTKey{{KID: "rsa", PrivateKey: pkcs8PEM(t, rsaKey)}}
+	j := newTestJWT(t, cfg)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	tests := map[string]string{
+		"HS256 with public key": signWith(t, jwt.SigningMethodHS256, "rsa", publicPEM),
+		"ES256":                 signWith(t, jwt.SigningMethodES256, "rsa", ecKey),
+		"EdDSA":                 signWith(t, jwt.SigningMethodEdDSA, "rsa", edKey),
+		"PS256":                 signWith(t, jwt.SigningMethodPS256, "rsa", rsaKey),
+		"none":                  signWith(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := j.ParseToken(token); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+				t.Fatalf("err = %v, want ErrTokenSignatureInvalid", err)
+			}
+		})
+	}
+}
+
+// TestJWKSDuringRotation 发布旧密钥、当前密钥和预发布密钥，不发布已过期的密钥
+func TestJWKSDuringRotation(t *testing.T) {
+	for _, kt := range keyTypes {
+		t.Run(kt.alg, func(t *testing.T) {
+			r := newRotation(t, kt.alg, kt.generate)
+			set, err := newTestJWT(t, r.cfg).JWKS()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]crypto.Signer{"previous": r.previous, "current": r.current, "next": r.next}
+			if len(set.Keys) != len(want) {
+				t.Fatalf("published %d keys, want %d", len(set.Keys), len(want))
+			}
+			for _, jwk := range set.Keys {
+				key, ok := want[jwk.Kid]
+				if !ok {
+					t.Fatalf("unexpected key %s", jwk.Kid)
+				}
+				if jwk.Alg != kt.alg || jwk.Use != "sig" {
+					t.Fatalf("jwk %s: alg = %s, use = %s", jwk.Kid, jwk.Alg, jwk.Use)
+				}
+				pub, err := jwk.PublicKey()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !key.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+					t.Fatalf("jwk %s does not round-trip to the signing key's public key", jwk.Kid)
+				}
+			}
+		})
+	}
+}
+
+func TestJWKSEmptyForHS256(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "secret"
+	set, err := newTestJWT(t, cfg).JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Keys == nil || len(set.Keys) != 0 {
+		t.Fatalf("keys = %v, want an empty list", set.Keys)
+	}
+}
+
+func TestLoadSigningKeys(t *testing.T) {
+	rsaKey := keyTypes[0].generate(t)
+	ecKey := keyTypes[1].generate(t)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey.(*rsa.PrivateKey))}))
+	sec1DER, err := x509.MarshalECPrivateKey(ecKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1 := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1DER}))
+
+	tests := []struct {
+		name    string
+		alg     string
+		keys    []config.JWTKey
+		wantErr bool
+	}{
+		{"pkcs8", "RS256", []config.JWTKey{{KID: "a", PrivateKey: pkcs8PEM(t, rsaKey)}}, false},
+		{"pkcs1", "RS256", []config.JWTKey{{KID: "a", PrivateKey: pkcs1}}, false},
+		{"sec1", "ES256", []config.JWTKey{{KID: "a", PrivateKey: sec1}}, false},
+		{"no keys", "RS256", nil, true},
+		{"missing kid", "RS256", []config.JWTKey{{PrivateKey: pkcs1}}, true},
+		{"duplicate kid", "RS256", []config.JWTKey{{KID: "a", PrivateKey: pkcs1}, {KID: "a", PrivateKey: pkcs1}}, true},
+		{"not pem", "RS256", []config.JWTKey{{KID: "a", PrivateKey: "secret"}}, true},
+		{"key type mismatch", "ES256", []config.JWTKey{{KID: "a", PrivateKey: pkcs1}}, true},
+		{"wrong curve", "ES256", []config.JWTKey{{KID: "a", PrivateKey: pkcs8PEM(t, p384Key)}}, true},
+		{"invalid not_before", "RS256", []config.JWTKey{{KID: "a", PrivateKey: pkcs1, NotBefore: "tomorrow"}}, true},
+		{"invalid expires_at", "RS256", []config.JWTKey{{KID: "a", PrivateKey: pkcs1, ExpiresAt: "2030-01-01"}}, true},
+		{"missing key file", "RS256", []config.JWTKey{{KID: "a", PrivateKeyFile: "does-not-exist.pem"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.JWT.Algorithm = tt.alg
+			cfg.JWT.Keys = tt.keys
+			if err := Validate(cfg); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
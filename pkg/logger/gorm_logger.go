@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -11,25 +12,44 @@ import (
 	gormlogger "gorm.io/gorm/logger"
 )
 
+// DefaultSlowThreshold 默认慢查询阈值
+const DefaultSlowThreshold = 200 * time.Millisecond
+
 // GormLogger 操作对象，实现 gormlogger.Interface
 type GormLogger struct {
-	ZapLogger     *zap.Logger
-	SlowThreshold time.Duration
+	ZapLogger *zap.Logger
+	// slowThreshold 慢查询阈值（纳秒），LogMode 返回的副本共享同一个值，可在运行时调整
+	slowThreshold *atomic.Int64
 }
 
-// NewGormLogger 外部调用。实例化一个 GormLogger 对象
-func NewGormLogger(logger *zap.Logger) GormLogger {
-	return GormLogger{
-		ZapLogger:     logger,                 // 使用传入的 logger.Logger 对象
-		SlowThreshold: 200 * time.Millisecond, // 慢查询阈值，单位为毫秒
+// NewGormLogger 外部调用。实例化一个 GormLogger 对象，slowThreshold 为 0 时使用默认阈值，负数关闭慢查询日志
+func NewGormLogger(logger *zap.Logger, slowThreshold time.Duration) GormLogger {
+	l := GormLogger{
+		ZapLogger:     logger, // 使用传入的 logger.Logger 对象
+		slowThreshold: new(atomic.Int64),
 	}
+	l.SetSlowThreshold(slowThreshold)
+	return l
+}
+
+// SetSlowThreshold 调整慢查询阈值
+func (l GormLogger) SetSlowThreshold(threshold time.Duration) {
+	if threshold == 0 {
+		threshold = DefaultSlowThreshold
+	}
+	l.slowThreshold.Store(int64(threshold))
+}
+
+// SlowThreshold 当前的慢查询阈值，不大于 0 表示关闭
+func (l GormLogger) SlowThreshold() time.Duration {
+	return time.Duration(l.slowThreshold.Load())
 }
 
 // LogMode 实现 gormlogger.Interface 的 LogMode 方法
 func (l GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
 	return GormLogger{
 		ZapLogger:     l.ZapLogger,
-		SlowThreshold: l.SlowThreshold,
+		slowThreshold: l.slowThreshold,
 	}
 }
 
@@ -75,7 +95,7 @@ func (l GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (strin
 	}
 
 	// 慢查询日志
-	if l.SlowThreshold != 0 && elapsed > l.SlowThreshold {
+	if threshold := l.SlowThreshold(); threshold > 0 && elapsed > threshold {
 		l.logger().Warn("Database Slow Log", logFields...)
 	}
 
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -33,6 +34,33 @@ func L() *Logger {
 // Logger wraps zap.Logger to provide helper functions.
 type Logger struct {
 	*zap.Logger
+	level zap.AtomicLevel
+}
+
+// ParseLevel 解析日志级别，为空时使用 info
+func ParseLevel(level string) (zapcore.Level, error) {
+	switch level {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "", "info":
+		return zapcore.InfoLevel, nil
+	case "warn":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("unknown log level: %q", level)
+	}
+}
+
+// SetLevel 在运行时调整日志级别，对所有共享该 Logger 的组件立即生效
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(lvl)
+	return nil
 }
 
 func NewLogger(cfg *config.Config) (*Logger, error) {
@@ -42,24 +70,13 @@ func NewLogger(cfg *config.Config) (*Logger, error) {
 		return nil, err
 	}
 
-	// 解析日志级别
-	var level zapcore.Level
-	switch cfg.Logger.Level {
-	case "debug":
-		level = zapcore.DebugLevel
-	case "info":
-		level = zapcore.InfoLevel
-	case "warn":
-		level = zapcore.WarnLevel
-	case "error":
-		level = zapcore.ErrorLevel
-	default:
-		level = zapcore.InfoLevel
-	}
+	// 解析日志级别，无法识别时使用 info
+	level, _ := ParseLevel(cfg.Logger.Level)
+	atomicLevel := zap.NewAtomicLevelAt(level)
 
 	// 配置 Zap
 	zapCfg := zap.Config{
-		Level:       zap.NewAtomicLevelAt(level),
+		Level:       atomicLevel,
 		Development: cfg.Server.Mode == "debug",
 		Encoding:    "json",
 		EncoderConfig: zapcore.EncoderConfig{
@@ -85,5 +102,5 @@ func NewLogger(cfg *config.Config) (*Logger, error) {
 		return nil, err
 	}
 
-	return &Logger{zlog}, nil
+	return &Logger{Logger: zlog, level: atomicLevel}, nil
 }
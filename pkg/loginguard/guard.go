@@ -0,0 +1,234 @@
+// Package loginguard 提供登录暴力破解防护
+//
+// 分别按账号和客户端 IP 统计连续失败次数，达到阈值后临时锁定。
+// 每次锁定的时长在上一次的基础上翻倍（指数退避），直到上限；
+// 超过统计窗口没有新的失败后，计数和退避等级清零。
+//
+// 状态保存在内存中，仅适用于单实例部署，重启后记录丢失。
+package loginguard
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"evaframe/pkg/config"
+
+	"github.com/google/wire"
+)
+
+var ProviderSet = wire.NewSet(NewGuard)
+
+const (
+	defaultMaxAttempts   = 5
+	defaultIPMaxAttempts = 20
+	defaultBaseLockout   = time.Minute
+	defaultMaxLockout    = time.Hour
+	defaultWindow        = 15 * time.Minute
+)
+
+// Scope 锁定的维度
+type Scope string
+
+const (
+	ScopeAccount Scope = "account"
+	ScopeIP      Scope = "ip"
+)
+
+// Options 锁定阈值
+type Options struct {
+	MaxAttempts   int           // 单个账号连续失败多少次后锁定
+	IPMaxAttempts int           // 单个 IP 连续失败多少次后锁定
+	BaseLockout   time.Duration // 第一次锁定的时长
+	MaxLockout    time.Duration // 锁定时长上限
+	Window        time.Duration // 超过该时长没有新的失败则清零
+}
+
+// Lockout 一次新触发的锁定，用于记录日志
+type Lockout struct {
+	Scope    Scope
+	Key      string
+	Failures int
+	Duration time.Duration
+	Until    time.Time
+}
+
+type entry struct {
+	failures    int
+	lockouts    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// lastActive 最近一次失败或锁定结束的时间，统计窗口从这里开始计算
+func (e *entry) lastActive() time.Time {
+	if e.lockedUntil.After(e.lastFailure) {
+		return e.lockedUntil
+	}
+	return e.lastFailure
+}
+
+// Guard 记录登录失败并判断是否允许继续尝试，可并发使用
+type Guard struct {
+	opts    Options
+	mu      sync.Mutex
+	entries map[string]*entry
+	now     func() time.Time
+}
+
+// NewGuard 根据配置创建登录防护 Provider
+func NewGuard(cfg *config.Config) *Guard {
+	return New(optionsFromConfig(cfg))
+}
+
+// New 创建登录防护，未设置的选项使用默认值
+func New(opts Options) *Guard {
+	return &Guard{
+		opts:    opts.withDefaults(),
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+// Reload 使用新配置的阈值，已有的失败计数和锁定保持不变
+func (g *Guard) Reload(cfg *config.Config) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.opts = optionsFromConfig(cfg).withDefaults()
+}
+
+func optionsFromConfig(cfg *config.Config) Options {
+	lockout := cfg.Lockout
+	return Options{
+		MaxAttempts:   lockout.MaxAttempts,
+		IPMaxAttempts: lockout.IPMaxAttempts,
+		BaseLockout:   lockout.BaseDuration,
+		MaxLockout:    lockout.MaxDuration,
+		Window:        lockout.Window,
+	}
+}
+
+// withDefaults 未设置的选项使用默认值
+func (opts Options) withDefaults() Options {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.IPMaxAttempts <= 0 {
+		opts.IPMaxAttempts = defaultIPMaxAttempts
+	}
+	if opts.BaseLockout <= 0 {
+		opts.BaseLockout = defaultBaseLockout
+	}
+	if opts.MaxLockout < opts.BaseLockout {
+		opts.MaxLockout = max(defaultMaxLockout, opts.BaseLockout)
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultWindow
+	}
+	return opts
+}
+
+// Check 返回账号或 IP 仍处于锁定状态的剩余时长，未锁定时返回 0
+func (g *Guard) Check(account, ip string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	var retryAfter time.Duration
+	for _, key := range g.keys(account, ip) {
+		if e, ok := g.entries[key]; ok && now.Before(e.lockedUntil) {
+			retryAfter = max(retryAfter, e.lockedUntil.Sub(now))
+		}
+	}
+	return retryAfter
+}
+
+// Fail 记录一次失败，返回本次新触发的锁定
+func (g *Guard) Fail(account, ip string) []Lockout {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.prune(now)
+
+	var lockouts []Lockout
+	if lockout, ok := g.fail(ScopeAccount, normalize(account), g.opts.MaxAttempts, now); ok {
+		lockouts = append(lockouts, lockout)
+	}
+	if lockout, ok := g.fail(ScopeIP, ip, g.opts.IPMaxAttempts, now); ok {
+		lockouts = append(lockouts, lockout)
+	}
+	return lockouts
+}
+
+// Succeed 登录成功后清除账号的失败记录
+//
+// IP 的记录不清除，否则攻击者可以穿插登录自己的账号来重置计数。
+func (g *Guard) Succeed(account string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.entries, string(ScopeAccount)+":"+normalize(account))
+}
+
+func (g *Guard) fail(scope Scope, key string, threshold int, now time.Time) (Lockout, bool) {
+	if key == "" {
+		return Lockout{}, false
+	}
+
+	id := string(scope) + ":" + key
+	e, ok := g.entries[id]
+	if !ok || now.Sub(e.lastActive()) > g.opts.Window {
+		e = &entry{}
+		g.entries[id] = e
+	}
+	e.failures++
+	e.lastFailure = now
+
+	if e.failures < threshold {
+		return Lockout{}, false
+	}
+
+	// 达到阈值后锁定，每次锁定时长翻倍
+	duration := g.opts.BaseLockout << min(e.lockouts, 30)
+	if duration <= 0 || duration > g.opts.MaxLockout {
+		duration = g.opts.MaxLockout
+	}
+	lockout := Lockout{
+		Scope:    scope,
+		Key:      key,
+		Failures: e.failures,
+		Duration: duration,
+		Until:    now.Add(duration),
+	}
+
+	e.failures = 0
+	e.lockouts++
+	e.lockedUntil = lockout.Until
+	return lockout, true
+}
+
+// prune 清理已解除锁定且超过统计窗口的记录
+func (g *Guard) prune(now time.Time) {
+	for id, e := range g.entries {
+		if now.Sub(e.lastActive()) > g.opts.Window {
+			delete(g.entries, id)
+		}
+	}
+}
+
+func (g *Guard) keys(account, ip string) []string {
+	keys := make([]string, 0, 2)
+	if account = normalize(account); account != "" {
+		keys = append(keys, string(ScopeAccount)+":"+account)
+	}
+	if ip != "" {
+		keys = append(keys, string(ScopeIP)+":"+ip)
+	}
+	return keys
+}
+
+// normalize 账号不区分大小写，避免通过改变大小写绕过计数
+func normalize(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
@@ -0,0 +1,78 @@
+package loginguard
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestGuard(now *time.Time) *Guard {
+	g := New(Options{
+		MaxAttempts:   3,
+		IPMaxAttempts: 5,
+		BaseLockout:   time.Minute,
+		MaxLockout:    5 * time.Minute,
+		Window:        10 * time.Minute,
+	})
+	g.now = func() time.Time { return *now }
+	return g
+}
+
+func TestAccountLockoutBacksOffExponentially(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := newTestGuard(&now)
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		for i := 0; i < 2; i++ {
+			if lockouts := g.Fail("A@example.com", ""); len(lockouts) != 0 {
+				t.Fatalf("locked after %d failures, want 3", i+1)
+			}
+		}
+		lockouts := g.Fail("a@example.com", "")
+		if len(lockouts) != 1 || lockouts[0].Scope != ScopeAccount || lockouts[0].Duration != want {
+			t.Fatalf("lockouts = %+v, want account locked for %s", lockouts, want)
+		}
+		if got := g.Check(" a@EXAMPLE.com", "10.0.0.1"); got != want {
+			t.Fatalf("Check = %s, want %s", got, want)
+		}
+
+		now = now.Add(want)
+		if got := g.Check("a@example.com", ""); got != 0 {
+			t.Fatalf("still locked after lockout expired: %s", got)
+		}
+	}
+}
+
+func TestSucceedResetsAccountButNotIP(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := newTestGuard(&now)
+
+	// 攻击者在同一 IP 上轮换账号，并穿插登录自己的账号
+	for i := 0; i < 4; i++ {
+		g.Fail("victim@example.com", "10.0.0.1")
+		g.Succeed("victim@example.com")
+	}
+	if got := g.Check("victim@example.com", ""); got != 0 {
+		t.Fatalf("account locked after successful login: %s", got)
+	}
+
+	lockouts := g.Fail("other@example.com", "10.0.0.1")
+	if len(lockouts) != 1 || lockouts[0].Scope != ScopeIP {
+		t.Fatalf("lockouts = %+v, want ip locked", lockouts)
+	}
+	if got := g.Check("anyone@example.com", "10.0.0.1"); got != time.Minute {
+		t.Fatalf("Check = %s, want 1m", got)
+	}
+}
+
+func TestFailuresResetAfterWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := newTestGuard(&now)
+
+	g.Fail("a@example.com", "")
+	g.Fail("a@example.com", "")
+	now = now.Add(11 * time.Minute)
+
+	if lockouts := g.Fail("a@example.com", ""); len(lockouts) != 0 {
+		t.Fatalf("lockouts = %+v, want failures outside the window to be forgotten", lockouts)
+	}
+}
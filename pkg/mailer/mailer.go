@@ -0,0 +1,81 @@
+// Package mailer 提供可插拔的邮件发送实现
+//
+// 生产环境使用 SMTP；开发环境可以使用 file 驱动把邮件写入目录查看；
+// 测试使用 memory 驱动在内存中收集邮件。
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"evaframe/pkg/config"
+
+	"github.com/google/wire"
+)
+
+var ProviderSet = wire.NewSet(NewMailer)
+
+// Message 一封纯文本邮件
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewMailer 根据配置创建邮件发送器 Provider
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	from := cfg.Mail.From
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	switch cfg.Mail.Driver {
+	case "", "file":
+		dir := cfg.Mail.FileDir
+		if dir == "" {
+			dir = "storage/mails"
+		}
+		return NewFileMailer(dir, from), nil
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail.SMTP.Host, cfg.Mail.SMTP.Port, cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, from), nil
+	case "memory":
+		return NewMemoryMailer(from), nil
+	default:
+		return nil, fmt.Errorf("不支持的邮件驱动: %s", cfg.Mail.Driver)
+	}
+}
+
+// withDefaultFrom 未指定发件人时使用默认发件人
+func withDefaultFrom(msg *Message, from string) *Message {
+	if msg.From != "" {
+		return msg
+	}
+	m := *msg
+	m.From = from
+	return &m
+}
+
+// encode 将邮件编码为 RFC 5322 格式
+func encode(msg *Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return buf.Bytes()
+}
@@ -2,32 +2,104 @@
 package middleware
 
 import (
+	"errors"
+
+	"evaframe/pkg/auth"
+	"evaframe/pkg/config"
 	"evaframe/pkg/jwt"
+	"evaframe/pkg/logger"
 	"evaframe/pkg/response"
+	"evaframe/pkg/revocation"
+	"evaframe/pkg/session"
 
 	"github.com/gin-gonic/gin"
 )
 
-// JWTAuth is a factory function to create a JWT authentication middleware.
-func NewAuthMiddleware(jwt *jwt.JWT) AuthMiddleware {
+// NewAuthMiddleware is a factory function to create an authentication middleware.
+// It accepts JWT access tokens and, when apiKeys is not nil, personal API keys
+// (recognized by auth.APIKeyPrefix) from the same token sources.
+// When sessions are enabled, a valid session cookie takes precedence over token sources;
+// a stale session cookie is cleared and the token sources are tried instead.
+func NewAuthMiddleware(cfg *config.Config, jwt *jwt.JWT, revoked revocation.Store, apiKeys auth.APIKeyResolver, sessions *session.Manager) (AuthMiddleware, error) {
+	sources, err := ParseTokenSources(cfg.Auth.TokenSources)
+	if err != nil {
+		return nil, err
+	}
+	realm := cfg.Auth.Realm
+
 	return func(c *gin.Context) {
-		tokenStr := c.Request.Header.Get("Authorization")
-		if tokenStr == "" {
-			response.Unauthorized(c, "未授权")
+		staleSession := false
+		if sessions.Enabled() {
+			if token := sessions.Token(c); token != "" {
+				if authenticateSession(c, sessions, token) {
+					return
+				}
+				staleSession = true
+			}
+		}
+
+		tokenStr, err := extractToken(c, sources)
+		if err != nil {
+			abortInvalidRequest(c, realm, err)
+			return
+		}
+		if tokenStr == "" && staleSession {
+			response.Unauthorized(c, "会话无效或已过期，请重新登录")
 			c.Abort()
 			return
 		}
+		if tokenStr == "" {
+			abortUnauthorized(c, realm, "", "", "未授权")
+			return
+		}
+
+		if apiKeys != nil && auth.IsAPIKey(tokenStr) {
+			principal, err := apiKeys.ResolveAPIKey(c.Request.Context(), tokenStr)
+			if errors.Is(err, auth.ErrInvalidAPIKey) {
+				abortUnauthorized(c, realm, bearerInvalidToken, "the api key is invalid, expired or revoked", "API 密钥无效、已过期或已吊销")
+				return
+			}
+			if err != nil {
+				logger.L().LogIf(err)
+				response.InternalError(c, "服务器内部错误，请稍后再试")
+				c.Abort()
+				return
+			}
+
+			auth.WithPrincipal(c, principal)
+			c.Next()
+			return
+		}
 
-		// Real token starts after "Bearer "
-		token, err := jwt.ParseToken(tokenStr[7:])
+		token, err := jwt.ParseToken(tokenStr)
+		if err != nil || token.ID == "" {
+			abortUnauthorized(c, realm, bearerInvalidToken, "the access token is invalid or expired", "令牌无效或已过期")
+			return
+		}
+
+		// Reject tokens that were revoked before they expired
+		isRevoked, err := revoked.IsRevoked(token.ID)
 		if err != nil {
-			response.Unauthorized(c, "令牌无效或已过期")
+			logger.L().LogIf(err)
+			response.InternalError(c, "服务器内部错误，请稍后再试")
 			c.Abort()
 			return
 		}
+		if isRevoked {
+			abortUnauthorized(c, realm, bearerInvalidToken, "the access token has been revoked", "令牌已被吊销")
+			return
+		}
 
-		// Store claims in context
-		c.Set("claims", token)
+		// Store the authenticated principal in both gin and request context
+		auth.WithPrincipal(c, &auth.Principal{
+			UserID:      token.UserID,
+			Email:       token.Email,
+			Roles:       token.Roles,
+			Permissions: token.Permissions,
+			TokenID:     token.ID,
+			ExpiresAt:   token.ExpiresAt.Time,
+			ClientID:    token.ClientID,
+		})
 		c.Next()
-	}
+	}, nil
 }
@@ -0,0 +1,274 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"evaframe/pkg/auth"
+	"evaframe/pkg/config"
+	"evaframe/pkg/jwt"
+	"evaframe/pkg/revocation"
+	"evaframe/pkg/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testConfig() *config.Config {
+	var cfg config.Config
+	cfg.JWT.Secret = "test-secret"
+	return &cfg
+}
+
+func newTestJWT(t *testing.T) *jwt.JWT {
+	t.Helper()
+	j, err := jwt.NewJWT(testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func newTestAuthMiddleware(t *testing.T, cfg *config.Config, j *jwt.JWT, store revocation.Store) gin.HandlerFunc {
+	t.Helper()
+	mw, err := NewAuthMiddleware(cfg, j, store, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gin.HandlerFunc(mw)
+}
+
+// TestAuthMiddlewareSetsPrincipal 固定认证中间件与下游 Handler 之间的约定：
+// 中间件写入的身份必须能通过 auth.FromContext 从 gin 上下文和请求 context 中读到
+func TestAuthMiddlewareSetsPrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	j := newTestJWT(t)
+
+	token, err := j.GenerateToken(jwt.Identity{
+		UserID:      7,
+		Email:       "a@example.com",
+		Permissions: []string{"users:list"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fromGin, fromRequest *auth.Principal
+	router := gin.New()
+	router.GET("/", newTestAuthMiddleware(t, testConfig(), j, revocation.NewMemoryStore()), func(c *gin.Context) {
+		fromGin, _ = auth.FromContext(c)
+		fromRequest, _ = auth.FromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	for name, p := range map[string]*auth.Principal{"gin": fromGin, "request": fromRequest} {
+		if p == nil {
+			t.Fatalf("%s context has no principal", name)
+		}
+		if p.UserID != 7 || p.Email != "a@example.com" || p.TokenID == "" || p.ExpiresAt.IsZero() {
+			t.Errorf("%s principal = %+v", name, p)
+		}
+		if !p.HasPermission("users:list") {
+			t.Errorf("%s principal lost permissions", name)
+		}
+	}
+}
+
+func TestAuthMiddlewareRejectsRevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	j := newTestJWT(t)
+	store := revocation.NewMemoryStore()
+
+	token, err := j.GenerateToken(jwt.Identity{UserID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := j.ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Revoke(claims.ID, claims.ExpiresAt.Time); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/", newTestAuthMiddleware(t, testConfig(), j, store), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+}
+
+// fakeAPIKeys 内存实现的 auth.APIKeyResolver
+type fakeAPIKeys map[string]*auth.Principal
+
+func (f fakeAPIKeys) ResolveAPIKey(ctx context.Context, key string) (*auth.Principal, error) {
+	if p, ok := f[key]; ok {
+		return p, nil
+	}
+	return nil, auth.ErrInvalidAPIKey
+}
+
+func TestAuthMiddlewareAcceptsAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	apiKeys := fakeAPIKeys{"eva_valid": {UserID: 7, APIKeyID: 3}}
+	mw, err := NewAuthMiddleware(testConfig(), newTestJWT(t), revocation.NewMemoryStore(), apiKeys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *auth.Principal
+	router := gin.New()
+	router.GET("/", gin.HandlerFunc(mw), func(c *gin.Context) {
+		got, _ = auth.FromContext(c)
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{"valid", "eva_valid", http.StatusOK},
+		{"unknown", "eva_unknown", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && (got == nil || !got.IsAPIKey() || got.UserID != 7) {
+				t.Fatalf("principal = %+v, want api key principal for user 7", got)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareSessionRequiresCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := testConfig()
+	cfg.Auth.Mode = "session"
+
+	sessions, err := session.NewManager(cfg, session.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess, token, err := sessions.Create(session.Identity{UserID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mw, err := NewAuthMiddleware(cfg, newTestJWT(t), revocation.NewMemoryStore(), nil, sessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *auth.Principal
+	router := gin.New()
+	router.Any("/", gin.HandlerFunc(mw), func(c *gin.Context) {
+		got, _ = auth.FromContext(c)
+		c.Status(http.StatusOK)
+	})
+
+	bearer, err := newTestJWT(t).GenerateToken(jwt.Identity{UserID: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		cookie   string
+		csrf     string
+		bearer   string
+		want     int
+		wantUser uint
+	}{
+		{"safe method without csrf", "GET", token, "", "", http.StatusOK, 7},
+		{"unsafe method without csrf", "POST", token, "", "", http.StatusForbidden, 0},
+		{"unsafe method with wrong csrf", "POST", token, "wrong", "", http.StatusForbidden, 0},
+		{"unsafe method with csrf", "POST", token, sess.CSRFToken, "", http.StatusOK, 7},
+		{"unknown session", "GET", "unknown", "", "", http.StatusUnauthorized, 0},
+		// 会话存在时优先使用会话，仍然要求 CSRF 令牌
+		{"session with bearer", "POST", token, "", bearer, http.StatusForbidden, 0},
+		// 过期的会话 Cookie 不妨碍使用其他令牌来源
+		{"unknown session with bearer", "POST", "unknown", "", bearer, http.StatusOK, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.AddCookie(&http.Cookie{Name: "evaframe_session", Value: tt.cookie})
+			if tt.csrf != "" {
+				req.Header.Set("X-CSRF-Token", tt.csrf)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && (got == nil || got.UserID != tt.wantUser || got.IsSession() != (tt.bearer == "")) {
+				t.Fatalf("principal = %+v, want user %d", got, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      int
+	}{
+		{"unauthenticated", nil, http.StatusUnauthorized},
+		{"missing permission", &auth.Principal{UserID: 1}, http.StatusForbidden},
+		{"granted", &auth.Principal{UserID: 1, Permissions: []string{"users:list"}}, http.StatusOK},
+		{"wildcard", &auth.Principal{UserID: 1, Permissions: []string{auth.PermissionAll}}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				if tt.principal != nil {
+					auth.WithPrincipal(c, tt.principal)
+				}
+			}, RequirePermission("users:list"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"evaframe/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "X-CSRF-Token"}
+)
+
+// corsPolicy is an immutable snapshot of the CORS settings.
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          []string
+	methods          string
+	headers          string
+	allowCredentials bool
+	maxAge           string
+}
+
+func newCORSPolicy(cfg *config.Config) *corsPolicy {
+	c := cfg.CORS
+	methods := c.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := c.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+
+	p := &corsPolicy{
+		anyOrigin:        slices.Contains(c.AllowedOrigins, "*"),
+		origins:          c.AllowedOrigins,
+		methods:          strings.Join(methods, ", "),
+		headers:          strings.Join(headers, ", "),
+		allowCredentials: c.AllowCredentials,
+	}
+	if c.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(c.MaxAge.Seconds()))
+	}
+	return p
+}
+
+func (p *corsPolicy) allows(origin string) bool {
+	return p.anyOrigin || slices.Contains(p.origins, origin)
+}
+
+// ValidateCORS rejects settings that browsers would refuse.
+func ValidateCORS(cfg *config.Config) error {
+	if cfg.CORS.AllowCredentials && slices.Contains(cfg.CORS.AllowedOrigins, "*") {
+		return errors.New("cors.allow_credentials cannot be used with the * origin")
+	}
+	return nil
+}
+
+// NewCORSMiddleware creates a CORS middleware whose settings follow config reloads.
+// With no allowed origins configured the middleware is a no-op. Otherwise requests from
+// origins that are not allowed pass through without CORS headers, and their preflight
+// requests are rejected with 403.
+func NewCORSMiddleware(holder *config.Holder) CORSMiddleware {
+	var policy atomic.Pointer[corsPolicy]
+	policy.Store(newCORSPolicy(holder.Get()))
+
+	holder.AddValidator(ValidateCORS)
+	holder.Subscribe(func(old, next *config.Config) {
+		policy.Store(newCORSPolicy(next))
+	})
+
+	return func(c *gin.Context) {
+		p := policy.Load()
+		origin := c.GetHeader("Origin")
+		if origin == "" || len(p.origins) == 0 {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if !p.allows(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if p.anyOrigin && !p.allowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if p.allowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			h.Set("Access-Control-Allow-Methods", p.methods)
+			h.Set("Access-Control-Allow-Headers", p.headers)
+			if p.maxAge != "" {
+				h.Set("Access-Control-Max-Age", p.maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -12,6 +12,8 @@ var ProviderSet = wire.NewSet(
 	NewLoggerMiddleware,
 	NewRecoveryMiddleware,
 	NewAuthMiddleware,
+	NewCORSMiddleware,
+	NewRateLimitMiddleware,
 )
 
 // AuthMiddleware is a custom type for auth middleware.
@@ -23,18 +25,28 @@ type LoggerMiddleware gin.HandlerFunc
 // RecoveryMiddleware is a custom type for recovery middleware.
 type RecoveryMiddleware gin.HandlerFunc
 
+// CORSMiddleware is a custom type for CORS middleware.
+type CORSMiddleware gin.HandlerFunc
+
+// RateLimitMiddleware is a custom type for rate limit middleware.
+type RateLimitMiddleware gin.HandlerFunc
+
 // Middlewares contains all middlewares.
 type Middlewares struct {
-	Logger   LoggerMiddleware
-	Auth     AuthMiddleware
-	Recovery RecoveryMiddleware
+	Logger    LoggerMiddleware
+	Auth      AuthMiddleware
+	Recovery  RecoveryMiddleware
+	CORS      CORSMiddleware
+	RateLimit RateLimitMiddleware
 }
 
 // NewMiddlewares creates a new Middlewares container.
-func NewMiddlewares(logger LoggerMiddleware, recovery RecoveryMiddleware, auth AuthMiddleware) *Middlewares {
+func NewMiddlewares(logger LoggerMiddleware, recovery RecoveryMiddleware, auth AuthMiddleware, cors CORSMiddleware, rateLimit RateLimitMiddleware) *Middlewares {
 	return &Middlewares{
-		Logger:   logger,
-		Auth:     auth,
-		Recovery: recovery,
+		Logger:    logger,
+		Auth:      auth,
+		Recovery:  recovery,
+		CORS:      cors,
+		RateLimit: rateLimit,
 	}
 }
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"strings"
+
+	"evaframe/pkg/auth"
+	"evaframe/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission creates a middleware that requires all of the given permissions.
+// It must be used after the auth middleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c)
+		if !ok {
+			response.Unauthorized(c, "未授权")
+			c.Abort()
+			return
+		}
+
+		for _, perm := range permissions {
+			if !principal.HasPermission(perm) {
+				abortInsufficientScope(c, strings.Join(permissions, " "))
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole creates a middleware that requires any one of the given roles.
+// It must be used after the auth middleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := auth.FromContext(c)
+		if !ok {
+			response.Unauthorized(c, "未授权")
+			c.Abort()
+			return
+		}
+
+		for _, role := range roles {
+			if principal.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+
+		response.Abort403(c, "权限不足")
+		c.Abort()
+	}
+}
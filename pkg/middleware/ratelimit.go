@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"evaframe/pkg/config"
+	"evaframe/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sweepInterval is how often idle buckets are dropped.
+const sweepInterval = time.Minute
+
+// bucket is a token bucket for a single client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter limits requests per client IP with token buckets.
+// Limits can be changed at runtime; existing buckets keep their tokens.
+type RateLimiter struct {
+	mu        sync.Mutex
+	enabled   bool
+	rate      float64 // tokens per second
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter from the rate_limit config section.
+func NewRateLimiter(cfg *config.Config) *RateLimiter {
+	l := &RateLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+	l.Reload(cfg)
+	return l
+}
+
+// ValidateRateLimit rejects an enabled rate limit without a positive rate.
+func ValidateRateLimit(cfg *config.Config) error {
+	if cfg.RateLimit.Enabled && cfg.RateLimit.Rate <= 0 {
+		return errors.New("rate_limit.rate must be positive when rate limiting is enabled")
+	}
+	return nil
+}
+
+// Reload applies the limits from cfg.
+func (l *RateLimiter) Reload(cfg *config.Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rl := cfg.RateLimit
+	l.enabled = rl.Enabled && rl.Rate > 0
+	l.rate = rl.Rate
+	l.burst = float64(rl.Burst)
+	if l.burst <= 0 {
+		l.burst = math.Max(1, math.Ceil(rl.Rate))
+	}
+}
+
+// Allow takes a token for key. When the bucket is empty it returns false
+// and how long the client should wait before retrying.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.enabled {
+		return true, 0
+	}
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have been idle long enough to be full again.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// NewRateLimitMiddleware creates a per-IP rate limit middleware whose limits follow config reloads.
+func NewRateLimitMiddleware(holder *config.Holder) RateLimitMiddleware {
+	limiter := NewRateLimiter(holder.Get())
+
+	holder.AddValidator(ValidateRateLimit)
+	holder.Subscribe(func(old, next *config.Config) {
+		limiter.Reload(next)
+	})
+
+	return func(c *gin.Context) {
+		ok, retryAfter := limiter.Allow(c.ClientIP())
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			response.TooManyRequests(c, "请求过于频繁，请稍后再试")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
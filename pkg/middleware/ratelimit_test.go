@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"evaframe/pkg/config"
+)
+
+func TestRateLimiterFollowsReload(t *testing.T) {
+	var cfg config.Config
+	cfg.RateLimit.Enabled = true
+	cfg.RateLimit.Rate = 1
+	cfg.RateLimit.Burst = 2
+
+	now := time.Now()
+	l := NewRateLimiter(&cfg)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("1.2.3.4"); !ok {
+			t.Fatalf("request %d within burst was limited", i+1)
+		}
+	}
+	ok, retryAfter := l.Allow("1.2.3.4")
+	if ok || retryAfter <= 0 || retryAfter > time.Second {
+		t.Fatalf("allow = %v, retryAfter = %v, want limited for at most 1s", ok, retryAfter)
+	}
+	if ok, _ := l.Allow("5.6.7.8"); !ok {
+		t.Fatal("other clients must not share the bucket")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := l.Allow("1.2.3.4"); !ok {
+		t.Fatal("bucket should refill over time")
+	}
+
+	cfg.RateLimit.Enabled = false
+	l.Reload(&cfg)
+	for i := 0; i < 10; i++ {
+		if ok, _ := l.Allow("1.2.3.4"); !ok {
+			t.Fatal("disabled limiter must allow all requests")
+		}
+	}
+}
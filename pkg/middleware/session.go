@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+
+	"evaframe/pkg/auth"
+	"evaframe/pkg/logger"
+	"evaframe/pkg/response"
+	"evaframe/pkg/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authenticateSession authenticates the request with a session cookie.
+// Unsafe methods must echo the session's CSRF token in the configured header,
+// because browsers attach the cookie to cross-site requests automatically.
+// It reports false without responding when the session no longer exists,
+// so that the caller can try the other token sources.
+func authenticateSession(c *gin.Context, sessions *session.Manager, token string) bool {
+	sess, err := sessions.Load(token)
+	if errors.Is(err, session.ErrNotFound) {
+		sessions.ClearCookie(c)
+		return false
+	}
+	if err != nil {
+		logger.L().LogIf(err)
+		response.InternalError(c, "服务器内部错误，请稍后再试")
+		c.Abort()
+		return true
+	}
+
+	if !sessions.VerifyCSRF(c, sess) {
+		response.Abort403(c, "CSRF 令牌无效")
+		c.Abort()
+		return true
+	}
+
+	auth.WithPrincipal(c, &auth.Principal{
+		UserID:      sess.UserID,
+		Email:       sess.Email,
+		Roles:       sess.Roles,
+		Permissions: sess.Permissions,
+		ExpiresAt:   sess.ExpiresAt,
+		SessionID:   sess.ID,
+	})
+	c.Next()
+	return true
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"evaframe/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RFC 6750 定义的错误码
+const (
+	bearerInvalidRequest    = "invalid_request"
+	bearerInvalidToken      = "invalid_token"
+	bearerInsufficientScope = "insufficient_scope"
+)
+
+var (
+	// ErrMalformedAuthorization Authorization 头不是合法的 Bearer 凭证
+	ErrMalformedAuthorization = errors.New("malformed authorization header")
+	// ErrMultipleCredentials 请求同时通过多种方式携带了令牌
+	ErrMultipleCredentials = errors.New("multiple credentials in request")
+)
+
+// TokenSource extracts an access token from a request.
+// An empty token without error means the source is absent.
+type TokenSource interface {
+	Extract(c *gin.Context) (string, error)
+}
+
+// ParseTokenSources parses token source specs such as
+// "header", "header:X-Access-Token", "cookie:access_token" and "query:access_token".
+func ParseTokenSources(specs []string) ([]TokenSource, error) {
+	if len(specs) == 0 {
+		specs = []string{"header"}
+	}
+
+	sources := make([]TokenSource, 0, len(specs))
+	for _, spec := range specs {
+		kind, name, _ := strings.Cut(spec, ":")
+		switch {
+		case kind == "header" && name == "":
+			sources = append(sources, bearerHeaderSource{})
+		case kind == "header":
+			sources = append(sources, headerSource{name: name})
+		case kind == "cookie" && name != "":
+			sources = append(sources, cookieSource{name: name})
+		case kind == "query" && name != "":
+			sources = append(sources, querySource{name: name})
+		default:
+			return nil, fmt.Errorf("invalid token source: %q", spec)
+		}
+	}
+	return sources, nil
+}
+
+// extractToken 依次从所有来源提取令牌，RFC 6750 要求客户端只能使用一种方式
+func extractToken(c *gin.Context, sources []TokenSource) (string, error) {
+	var found string
+	for _, source := range sources {
+		token, err := source.Extract(c)
+		if err != nil {
+			return "", err
+		}
+		if token == "" {
+			continue
+		}
+		if found != "" {
+			return "", ErrMultipleCredentials
+		}
+		found = token
+	}
+	return found, nil
+}
+
+// ParseBearer parses an Authorization header value of the form "Bearer <token68>" (RFC 6750 §2.1).
+// The scheme is case-insensitive.
+func ParseBearer(header string) (string, error) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", ErrMalformedAuthorization
+	}
+
+	token = strings.TrimLeft(token, " ")
+	if !isToken68(token) {
+		return "", ErrMalformedAuthorization
+	}
+	return token, nil
+}
+
+// isToken68 token68 = 1*( ALPHA / DIGIT / "-" / "." / "_" / "~" / "+" / "/" ) *"="
+func isToken68(s string) bool {
+	body := strings.TrimRight(s, "=")
+	if body == "" {
+		return false
+	}
+	for _, r := range body {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("-._~+/", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// bearerHeaderSource 标准的 Authorization: Bearer 请求头
+type bearerHeaderSource struct{}
+
+func (bearerHeaderSource) Extract(c *gin.Context) (string, error) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		return "", nil
+	}
+	return ParseBearer(header)
+}
+
+// headerSource 自定义请求头，值即为令牌
+type headerSource struct {
+	name string
+}
+
+func (s headerSource) Extract(c *gin.Context) (string, error) {
+	return strings.TrimSpace(c.GetHeader(s.name)), nil
+}
+
+// cookieSource Cookie，适用于浏览器端
+type cookieSource struct {
+	name string
+}
+
+func (s cookieSource) Extract(c *gin.Context) (string, error) {
+	token, err := c.Cookie(s.name)
+	if errors.Is(err, http.ErrNoCookie) {
+		return "", nil
+	}
+	return token, err
+}
+
+// querySource 查询参数，仅在 WebSocket 握手时生效，
+// 因为浏览器的 WebSocket API 无法设置请求头；普通请求不接受，避免令牌进入访问日志
+type querySource struct {
+	name string
+}
+
+func (s querySource) Extract(c *gin.Context) (string, error) {
+	if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		return "", nil
+	}
+	return c.Query(s.name), nil
+}
+
+// bearerChallenge 构造 WWW-Authenticate 头（RFC 6750 §3）
+func bearerChallenge(realm, code, description, scope string) string {
+	params := make([]string, 0, 4)
+	if realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", realm))
+	}
+	if code != "" {
+		params = append(params, fmt.Sprintf("error=%q", code))
+	}
+	if description != "" {
+		params = append(params, fmt.Sprintf("error_description=%q", description))
+	}
+	if scope != "" {
+		params = append(params, fmt.Sprintf("scope=%q", scope))
+	}
+	if len(params) == 0 {
+		return "Bearer"
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// abortUnauthorized 返回 401，code 为空表示请求未携带凭证（RFC 6750 §3.1 此时不返回错误码）
+func abortUnauthorized(c *gin.Context, realm, code, description, message string) {
+	c.Header("WWW-Authenticate", bearerChallenge(realm, code, description, ""))
+	response.Unauthorized(c, message)
+	c.Abort()
+}
+
+// abortInvalidRequest 返回 400 invalid_request，属于客户端错误，不记录错误日志
+func abortInvalidRequest(c *gin.Context, realm string, err error) {
+	c.Header("WWW-Authenticate", bearerChallenge(realm, bearerInvalidRequest, err.Error(), ""))
+	c.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+		Message: "认证请求格式错误",
+		Error:   err.Error(),
+	})
+}
+
+// abortInsufficientScope 返回 403 insufficient_scope
+func abortInsufficientScope(c *gin.Context, scope string) {
+	c.Header("WWW-Authenticate", bearerChallenge("", bearerInsufficientScope, "", scope))
+	response.Abort403(c, "权限不足")
+	c.Abort()
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseBearer(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{"Bearer abc.def-ghi_~+/==", "abc.def-ghi_~+/==", false},
+		{"bearer abc", "abc", false},
+		{"BEARER   abc", "abc", false},
+		{"Bearer", "", true},
+		{"Bearer ", "", true},
+		{"Bearer a b", "", true},
+		{"Basic dXNlcjpwYXNz", "", true},
+		{"Bearerabc", "", true},
+		{"abc", "", true},
+		{"Token abcdefgh", "", true},
+		{"Bearer ===", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBearer(tt.header)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseBearer(%q) = %q, %v; want %q, err=%v", tt.header, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseTokenSourcesRejectsInvalidSpec(t *testing.T) {
+	for _, spec := range []string{"cookie", "query:", "body:token", ""} {
+		if _, err := ParseTokenSources([]string{spec}); err == nil {
+			t.Errorf("ParseTokenSources(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestExtractToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sources, err := ParseTokenSources([]string{"header", "header:X-Access-Token", "cookie:access_token", "query:access_token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(r *http.Request)
+		want    string
+		wantErr error
+	}{
+		{"none", func(r *http.Request) {}, "", nil},
+		{"authorization", func(r *http.Request) { r.Header.Set("Authorization", "Bearer a1") }, "a1", nil},
+		{"malformed", func(r *http.Request) { r.Header.Set("Authorization", "Bear") }, "", ErrMalformedAuthorization},
+		{"custom header", func(r *http.Request) { r.Header.Set("X-Access-Token", "h1") }, "h1", nil},
+		{"cookie", func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "access_token", Value: "c1"}) }, "c1", nil},
+		{"query without upgrade", func(r *http.Request) { r.URL.RawQuery = "access_token=q1" }, "", nil},
+		{"query on websocket", func(r *http.Request) {
+			r.URL.RawQuery = "access_token=q1"
+			r.Header.Set("Upgrade", "websocket")
+		}, "q1", nil},
+		{"multiple", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer a1")
+			r.AddCookie(&http.Cookie{Name: "access_token", Value: "c1"})
+		}, "", ErrMultipleCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/", nil)
+			tt.setup(c.Request)
+
+			got, err := extractToken(c, sources)
+			if err != tt.wantErr || got != tt.want {
+				t.Fatalf("extractToken() = %q, %v; want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareChallenges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := testConfig()
+	cfg.Auth.Realm = "evaframe"
+	router := gin.New()
+	router.GET("/", newTestAuthMiddleware(t, cfg, newTestJWT(t), nil), func(c *gin.Context) {})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantHeader string
+	}{
+		{"missing", "", http.StatusUnauthorized, `Bearer realm="evaframe"`},
+		{"malformed", "Bearer", http.StatusBadRequest, `error="invalid_request"`},
+		{"invalid", "Bearer not.a.jwt", http.StatusUnauthorized, `error="invalid_token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); !strings.Contains(got, tt.wantHeader) {
+				t.Fatalf("WWW-Authenticate = %q, want it to contain %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
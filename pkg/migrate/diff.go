@@ -0,0 +1,509 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// Change 模型与数据库结构之间的一项差异及对应的 SQL
+type Change struct {
+	Description string
+	// Destructive 执行后可能丢失数据（删除表或列、修改列类型），需要人工确认
+	Destructive bool
+	// Manual 无法自动生成 SQL，需要手写，Up 和 Down 为空
+	Manual bool
+	Up     []string
+	Down   []string
+}
+
+// Diff 比较模型与数据库的当前结构，返回创建或删除表、增删改列、增删索引所需的变更。
+// 数据库中有但模型中没有的表也会被删除，迁移记录表除外。不比较外键和列的默认值
+func Diff(db *gorm.DB, models []any) ([]Change, error) {
+	d := newDiffer(db)
+
+	// 按依赖排序，并补上多对多关联表
+	if reorder, ok := d.read.Migrator().(interface {
+		ReorderModels([]any, bool) []any
+	}); ok {
+		models = reorder.ReorderModels(models, true)
+	}
+
+	tables, err := d.read.Migrator().GetTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	live := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		if !internalTable(table) {
+			live[table] = true
+		}
+	}
+
+	var (
+		changes []Change
+		seen    = make(map[string]bool)
+	)
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: d.read}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		if seen[stmt.Table] {
+			continue
+		}
+		seen[stmt.Table] = true
+
+		if !live[stmt.Table] {
+			change, err := d.createTable(model, stmt)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+			continue
+		}
+		tableChanges, err := d.compareTable(model, stmt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, tableChanges...)
+	}
+
+	for _, table := range tables {
+		if live[table] && !seen[table] {
+			change, err := d.dropTable(table)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// internalTable 迁移自身使用的表和 SQLite 的系统表
+func internalTable(table string) bool {
+	return table == (SchemaMigration{}).TableName() || table == (migrationLock{}).TableName() ||
+		strings.HasPrefix(table, "sqlite_")
+}
+
+// differ 读取数据库结构，并用 DryRun 模式让 GORM 生成 DDL 而不执行
+type differ struct {
+	dialect string
+	read    *gorm.DB
+	dry     *gorm.DB
+	rec     *recorder
+}
+
+func newDiffer(db *gorm.DB) *differ {
+	rec := &recorder{}
+	return &differ{
+		dialect: db.Dialector.Name(),
+		read:    db.Session(&gorm.Session{Logger: logger.Discard}),
+		dry:     db.Session(&gorm.Session{DryRun: true, Logger: rec}),
+		rec:     rec,
+	}
+}
+
+// capture 返回 fn 在 DryRun 模式下生成的 SQL
+func (d *differ) capture(fn func(m gorm.Migrator) error) ([]string, error) {
+	d.rec.statements = nil
+	if err := fn(d.dry.Migrator()); err != nil {
+		return nil, err
+	}
+	return d.rec.statements, nil
+}
+
+// exec 返回单条语句渲染后的 SQL，参数中的表名、列名按数据库规则加引号
+func (d *differ) exec(sql string, vars ...any) string {
+	d.rec.statements = nil
+	d.dry.Exec(sql, vars...)
+	return strings.Join(d.rec.statements, ";\n")
+}
+
+func (d *differ) createTable(model any, stmt *gorm.Statement) (Change, error) {
+	up, err := d.capture(func(m gorm.Migrator) error { return m.CreateTable(model) })
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to generate create table %s: %w", stmt.Table, err)
+	}
+	return Change{
+		Description: "create table " + stmt.Table,
+		Up:          up,
+		Down:        []string{d.exec("DROP TABLE ?", clause.Table{Name: stmt.Table})},
+	}, nil
+}
+
+func (d *differ) dropTable(table string) (Change, error) {
+	columns, err := d.read.Migrator().ColumnTypes(table)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	indexes, err := d.read.Migrator().GetIndexes(table)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to read indexes of %s: %w", table, err)
+	}
+
+	// 回滚时按删除前的结构重建，数据无法恢复
+	var (
+		defs []string
+		pk   []string
+	)
+	for _, col := range columns {
+		defs = append(defs, d.exec("?", clause.Column{Name: col.Name()})+" "+d.liveDefinition(col))
+		if isPK, ok := col.PrimaryKey(); ok && isPK {
+			pk = append(pk, d.exec("?", clause.Column{Name: col.Name()}))
+		}
+	}
+	if len(pk) > 0 {
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(pk, ",")+")")
+	}
+	down := []string{d.exec("CREATE TABLE ? (", clause.Table{Name: table}) + strings.Join(defs, ",") + ")"}
+	for _, idx := range indexes {
+		if isPK, _ := idx.PrimaryKey(); !isPK {
+			down = append(down, d.createLiveIndex(table, idx))
+		}
+	}
+
+	return Change{
+		Description: "drop table " + table,
+		Destructive: true,
+		Up:          []string{d.exec("DROP TABLE ?", clause.Table{Name: table})},
+		Down:        down,
+	}, nil
+}
+
+// compareTable 比较已存在的表，变更顺序为：删除索引、增加列、修改列、删除列、创建索引，
+// 保证删除列之前先删除它上面的索引，创建索引时列已经存在
+func (d *differ) compareTable(model any, stmt *gorm.Statement) ([]Change, error) {
+	table := stmt.Table
+	columns, err := d.read.Migrator().ColumnTypes(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	liveIndexes, err := d.read.Migrator().GetIndexes(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes of %s: %w", table, err)
+	}
+
+	liveColumns := make(map[string]gorm.ColumnType, len(columns))
+	for _, col := range columns {
+		liveColumns[col.Name()] = col
+	}
+
+	var dropIndexes, addColumns, alterColumns, dropColumns, createIndexes []Change
+
+	// 索引
+	wantIndexes := make(map[string]*schema.Index)
+	for _, idx := range stmt.Schema.ParseIndexes() {
+		wantIndexes[idx.Name] = idx
+	}
+	uniqueConstraints := make(map[string]bool)
+	for _, field := range stmt.Schema.Fields {
+		if field.Unique {
+			uniqueConstraints[d.read.NamingStrategy.UniqueName(table, field.DBName)] = true
+		}
+	}
+	existing := make(map[string]bool)
+	for _, idx := range liveIndexes {
+		if isPK, _ := idx.PrimaryKey(); isPK || uniqueConstraints[idx.Name()] {
+			continue
+		}
+		// 名称相同但列或唯一性不同的索引先删除，再和新索引一起创建
+		if want, ok := wantIndexes[idx.Name()]; ok && sameIndex(want, idx) {
+			existing[idx.Name()] = true
+			continue
+		}
+		up, err := d.capture(func(m gorm.Migrator) error { return m.DropIndex(table, idx.Name()) })
+		if err != nil {
+			return nil, err
+		}
+		dropIndexes = append(dropIndexes, Change{Description: fmt.Sprintf("drop index %s on %s", idx.Name(), table), Up: up, Down: []string{d.createLiveIndex(table, idx)}})
+	}
+	for _, idx := range stmt.Schema.ParseIndexes() {
+		if existing[idx.Name] {
+			continue
+		}
+		up, err := d.capture(func(m gorm.Migrator) error { return m.CreateIndex(model, idx.Name) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate index %s: %w", idx.Name, err)
+		}
+		down, err := d.capture(func(m gorm.Migrator) error { return m.DropIndex(table, idx.Name) })
+		if err != nil {
+			return nil, err
+		}
+		createIndexes = append(createIndexes, Change{Description: fmt.Sprintf("create index %s on %s", idx.Name, table), Up: up, Down: down})
+	}
+
+	// 列
+	for _, name := range stmt.Schema.DBNames {
+		field := stmt.Schema.FieldsByDBName[name]
+		if field.IgnoreMigration {
+			continue
+		}
+		col, ok := liveColumns[name]
+		if !ok {
+			up, err := d.capture(func(m gorm.Migrator) error { return m.AddColumn(model, name) })
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate column %s.%s: %w", table, name, err)
+			}
+			addColumns = append(addColumns, Change{
+				Description: fmt.Sprintf("add column %s.%s", table, name),
+				Up:          up,
+				Down:        []string{d.dropColumn(table, name)},
+			})
+			continue
+		}
+		change, changed, err := d.alterColumn(model, table, field, col)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			alterColumns = append(alterColumns, change)
+		}
+	}
+	for _, col := range columns {
+		if _, ok := stmt.Schema.FieldsByDBName[col.Name()]; ok {
+			continue
+		}
+		dropColumns = append(dropColumns, Change{
+			Description: fmt.Sprintf("drop column %s.%s", table, col.Name()),
+			Destructive: true,
+			Up:          []string{d.dropColumn(table, col.Name())},
+			Down:        []string{d.exec("ALTER TABLE ? ADD ? ", clause.Table{Name: table}, clause.Column{Name: col.Name()}) + d.liveDefinition(col)},
+		})
+	}
+
+	return slices.Concat(dropIndexes, addColumns, alterColumns, dropColumns, createIndexes), nil
+}
+
+func (d *differ) dropColumn(table, column string) string {
+	return d.exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: column})
+}
+
+// alterColumn 比较列的类型、长度和是否可为空，主键列不比较
+func (d *differ) alterColumn(model any, table string, field *schema.Field, col gorm.ColumnType) (Change, bool, error) {
+	if field.PrimaryKey {
+		return Change{}, false, nil
+	}
+
+	want := strings.ToLower(d.dataTypeOf(field))
+	typeChanged := !d.sameType(want, col)
+	nullable, ok := col.Nullable()
+	nullChanged := ok && nullable == field.NotNull
+	if !typeChanged && !nullChanged {
+		return Change{}, false, nil
+	}
+
+	liveType, _ := col.ColumnType()
+	change := Change{
+		Description: fmt.Sprintf("alter column %s.%s", table, field.DBName),
+		Destructive: typeChanged,
+	}
+	if typeChanged {
+		change.Description += fmt.Sprintf(" type %s -> %s", strings.ToLower(liveType), want)
+	}
+	if nullChanged {
+		change.Description += fmt.Sprintf(" not null %t -> %t", !nullable, field.NotNull)
+	}
+
+	tableExpr, column := clause.Table{Name: table}, clause.Column{Name: field.DBName}
+	switch d.dialect {
+	case "mysql":
+		up, err := d.capture(func(m gorm.Migrator) error { return m.AlterColumn(model, field.DBName) })
+		if err != nil {
+			return Change{}, false, err
+		}
+		change.Up = up
+		change.Down = []string{d.exec("ALTER TABLE ? MODIFY COLUMN ? ", tableExpr, column) + d.liveDefinition(col)}
+	case "postgres":
+		if typeChanged {
+			change.Up = append(change.Up, d.exec("ALTER TABLE ? ALTER COLUMN ? TYPE "+want+" USING ?::"+want, tableExpr, column, column))
+			change.Down = append(change.Down, d.exec("ALTER TABLE ? ALTER COLUMN ? TYPE "+liveType+" USING ?::"+liveType, tableExpr, column, column))
+		}
+		if nullChanged {
+			set, unset := "SET NOT NULL", "DROP NOT NULL"
+			if !field.NotNull {
+				set, unset = unset, set
+			}
+			change.Up = append(change.Up, d.exec("ALTER TABLE ? ALTER COLUMN ? "+set, tableExpr, column))
+			change.Down = append(change.Down, d.exec("ALTER TABLE ? ALTER COLUMN ? "+unset, tableExpr, column))
+		}
+	default:
+		// SQLite 不支持修改列，需要新建表、复制数据后替换
+		change.Manual = true
+	}
+	return change, true, nil
+}
+
+func (d *differ) dataTypeOf(field *schema.Field) string {
+	if m, ok := d.read.Migrator().(interface{ DataTypeOf(*schema.Field) string }); ok {
+		return m.DataTypeOf(field)
+	}
+	return d.read.Dialector.DataTypeOf(field)
+}
+
+// sameType 与 GORM AutoMigrate 的判断方式一致：类型名相同（包括别名）且长度相同
+func (d *differ) sameType(want string, col gorm.ColumnType) bool {
+	got := strings.ToLower(col.DatabaseTypeName())
+	same := strings.HasPrefix(want, got)
+	for _, alias := range d.read.Migrator().GetTypeAliases(got) {
+		same = same || strings.HasPrefix(want, alias)
+	}
+	if !same {
+		return false
+	}
+
+	if length, ok := col.Length(); ok && length > 0 {
+		if open := strings.Index(want, "("); open >= 0 {
+			size, _, _ := strings.Cut(want[open+1:], ")")
+			size, _, _ = strings.Cut(size, ",")
+			return strings.TrimSpace(size) == fmt.Sprint(length)
+		}
+	}
+	return true
+}
+
+// liveDefinition 按数据库中的现有结构生成列定义，用于回滚删除列或修改列
+func (d *differ) liveDefinition(col gorm.ColumnType) string {
+	def, ok := col.ColumnType()
+	if !ok || def == "" {
+		def = col.DatabaseTypeName()
+	}
+	if nullable, ok := col.Nullable(); ok && !nullable {
+		def += " NOT NULL"
+	}
+	// PostgreSQL 自增列的序列随表一起删除，不能作为默认值恢复
+	if value, ok := col.DefaultValue(); ok && value != "" && !strings.HasPrefix(value, "nextval(") {
+		def += " DEFAULT " + d.defaultLiteral(value)
+	}
+	return def
+}
+
+// defaultLiteral MySQL 返回的字符串默认值不带引号
+func (d *differ) defaultLiteral(value string) string {
+	if d.dialect != "mysql" || strings.EqualFold(value, "NULL") || strings.HasPrefix(strings.ToUpper(value), "CURRENT_TIMESTAMP") {
+		return value
+	}
+	if _, err := fmt.Sscanf(value, "%g", new(float64)); err == nil {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// createLiveIndex 按数据库中的现有索引生成创建语句，用于回滚删除索引
+func (d *differ) createLiveIndex(table string, idx gorm.Index) string {
+	columns := make([]any, 0, len(idx.Columns()))
+	placeholders := make([]string, 0, len(idx.Columns()))
+	for _, c := range idx.Columns() {
+		columns = append(columns, clause.Column{Name: c})
+		placeholders = append(placeholders, "?")
+	}
+	sql := "CREATE INDEX ? ON ? (" + strings.Join(placeholders, ",") + ")"
+	if unique, _ := idx.Unique(); unique {
+		sql = "CREATE UNIQUE INDEX ? ON ? (" + strings.Join(placeholders, ",") + ")"
+	}
+	return d.exec(sql, append([]any{clause.Column{Name: idx.Name()}, clause.Table{Name: table}}, columns...)...)
+}
+
+// sameIndex 比较索引的列和唯一性
+func sameIndex(want *schema.Index, got gorm.Index) bool {
+	columns := make([]string, 0, len(want.Fields))
+	for _, f := range want.Fields {
+		columns = append(columns, f.DBName)
+	}
+	unique, _ := got.Unique()
+	return slices.Equal(columns, got.Columns()) && unique == (want.Class == "UNIQUE")
+}
+
+// WriteDiff 把变更写成一对 SQL 迁移文件，文件名带数据库类型后缀，返回文件路径。
+// 破坏性变更和需要手写的变更在文件中用注释标出
+func WriteDiff(dir, name, dialect string, changes []Change, now time.Time) ([]string, error) {
+	name = strings.Trim(nameSeparator.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	version := now.UTC().Format("20060102150405")
+	header := fmt.Sprintf("-- %s_%s：由 migrate diff 根据模型与 %s 数据库的差异生成，执行前请检查\n", version, name, dialect)
+
+	var up, down strings.Builder
+	up.WriteString(header)
+	down.WriteString(header)
+	for _, c := range changes {
+		writeChange(&up, c)
+	}
+	// 回滚按相反的顺序撤销每一项变更
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		down.WriteString("\n-- 回滚 " + c.Description + "\n")
+		for _, stmt := range c.Down {
+			down.WriteString(stmt + ";\n")
+		}
+	}
+
+	contents := map[string]string{"up": up.String(), "down": down.String()}
+	var paths []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.%s.sql", version, name, direction, dialect))
+		if err := writeNew(path, contents[direction]); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writeChange(b *strings.Builder, c Change) {
+	b.WriteString("\n")
+	switch {
+	case c.Manual:
+		b.WriteString("-- MANUAL: " + c.Description + "，数据库不支持直接修改，需要手写\n")
+	case c.Destructive:
+		b.WriteString("-- DESTRUCTIVE: " + c.Description + "，可能丢失数据\n")
+	default:
+		b.WriteString("-- " + c.Description + "\n")
+	}
+	for _, stmt := range c.Up {
+		b.WriteString(stmt + ";\n")
+	}
+}
+
+func writeNew(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recorder 记录 DryRun 模式下生成的 SQL
+type recorder struct {
+	statements []string
+}
+
+func (r *recorder) LogMode(logger.LogLevel) logger.Interface { return r }
+func (r *recorder) Info(context.Context, string, ...any)     {}
+func (r *recorder) Warn(context.Context, string, ...any)     {}
+func (r *recorder) Error(context.Context, string, ...any)    {}
+
+func (r *recorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// lockName MySQL GET_LOCK 的锁名
+	lockName = "evaframe_schema_migrations"
+	// lockKey PostgreSQL advisory lock 的键
+	lockKey int64 = 7_318_220_114
+
+	// lockPollInterval SQLite 等待锁时的轮询间隔
+	lockPollInterval = 200 * time.Millisecond
+	// staleLockAge SQLite 的锁超过该时间视为持有者已经崩溃，可以被抢占
+	staleLockAge = 15 * time.Minute
+)
+
+// migrationLock SQLite 没有会话级锁，用单行表模拟
+type migrationLock struct {
+	ID       int       `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string    `gorm:"size:64;not null"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+func (migrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// acquireLock 获取迁移锁，ctx 超时前一直等待，返回释放锁的函数
+func acquireLock(ctx context.Context, db *gorm.DB) (func(), error) {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return sessionLock(ctx, db,
+			"SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", lockKey)
+	case "mysql":
+		return mysqlLock(ctx, db)
+	case "sqlite":
+		return tableLock(ctx, db)
+	default:
+		return nil, fmt.Errorf("migration lock is not supported for %s", db.Dialector.Name())
+	}
+}
+
+// sessionLock 会话级锁必须在同一个连接上获取和释放，因此从连接池中固定一个连接
+func sessionLock(ctx context.Context, db *gorm.DB, lockSQL, unlockSQL string, arg any) (func(), error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, lockSQL, arg); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	return func() {
+		// 连接关闭时数据库也会释放锁，这里显式释放以便连接回到连接池后可以复用
+		_, _ = conn.ExecContext(context.Background(), unlockSQL, arg)
+		conn.Close()
+	}, nil
+}
+
+// mysqlLock GET_LOCK 超时返回 0 而不是错误，需要检查返回值
+func mysqlLock(ctx context.Context, db *gorm.DB) (func(), error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := -1
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = max(int(time.Until(deadline).Seconds()), 0)
+	}
+	var got *int
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, timeout).Scan(&got); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if got == nil || *got != 1 {
+		conn.Close()
+		return nil, errors.New("timed out waiting for migration lock, another migration may be running")
+	}
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+		conn.Close()
+	}, nil
+}
+
+// tableLock 插入固定主键的行作为锁，插入成功即获得锁；超过 staleLockAge 的锁被清理
+func tableLock(ctx context.Context, db *gorm.DB) (func(), error) {
+	if err := db.AutoMigrate(&migrationLock{}); err != nil {
+		return nil, fmt.Errorf("failed to create migration lock table: %w", err)
+	}
+
+	owner, err := lockOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := db.Delete(&migrationLock{}, "locked_at < ?", time.Now().Add(-staleLockAge)).Error; err != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&migrationLock{ID: 1, Owner: owner, LockedAt: time.Now()})
+		if res.Error != nil {
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", res.Error)
+		}
+		if res.RowsAffected == 1 {
+			return func() {
+				db.Delete(&migrationLock{}, "id = ? AND owner = ?", 1, owner)
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("timed out waiting for migration lock, another migration may be running")
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func lockOwner() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,312 @@
+// Package migrate 版本化的数据库迁移：Go 函数或 SQL 文件定义的迁移按版本号顺序执行，
+// 已执行的版本记录在 schema_migrations 表中，执行期间持有数据库锁，避免多个实例同时迁移
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// versionPattern 版本号为 14 位时间戳，如 20261018093000
+var versionPattern = regexp.MustCompile(`^\d{14}$`)
+
+// Migration 单个迁移
+type Migration struct {
+	Version string // 14 位时间戳，决定执行顺序
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为 nil 时不能回滚
+	// NoTransaction 不在事务中执行，用于 CREATE INDEX CONCURRENTLY 等不能放在事务中的语句
+	NoTransaction bool
+	// Source 迁移的来源，Go 迁移为空，SQL 迁移为文件路径
+	Source string
+}
+
+// SchemaMigration 已执行的迁移
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;size:32"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 迁移的执行状态
+type Status struct {
+	Version   string
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Missing 数据库中有执行记录，但代码和迁移目录中已经没有这个迁移
+	Missing bool
+}
+
+// Migrator 执行迁移
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []*Migration
+	byVersion   map[string]*Migration
+	lockTimeout time.Duration
+
+	// Logf 输出执行进度，默认不输出
+	Logf func(format string, args ...any)
+}
+
+// DefaultLockTimeout 等待其他实例释放迁移锁的最长时间
+const DefaultLockTimeout = 5 * time.Minute
+
+// ErrIrreversible 迁移没有定义 Down
+var ErrIrreversible = errors.New("migration is irreversible")
+
+// New 创建 Migrator，migrations 为 Go 迁移和 SQL 迁移的并集，版本号不能重复
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	m := &Migrator{
+		db:          db,
+		byVersion:   make(map[string]*Migration, len(migrations)),
+		lockTimeout: DefaultLockTimeout,
+		Logf:        func(string, ...any) {},
+	}
+	for i := range migrations {
+		mg := &migrations[i]
+		if !versionPattern.MatchString(mg.Version) {
+			return nil, fmt.Errorf("migration %s_%s: version must be a 14 digit timestamp such as 20060102150405", mg.Version, mg.Name)
+		}
+		if mg.Up == nil {
+			return nil, fmt.Errorf("migration %s_%s has no up", mg.Version, mg.Name)
+		}
+		if existing, ok := m.byVersion[mg.Version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %s: %s and %s", mg.Version, describe(existing), describe(mg))
+		}
+		m.byVersion[mg.Version] = mg
+		m.migrations = append(m.migrations, mg)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool { return m.migrations[i].Version < m.migrations[j].Version })
+	return m, nil
+}
+
+// SetLockTimeout 设置等待迁移锁的最长时间
+func (m *Migrator) SetLockTimeout(timeout time.Duration) {
+	m.lockTimeout = timeout
+}
+
+func describe(mg *Migration) string {
+	if mg.Source != "" {
+		return mg.Source
+	}
+	return mg.Version + "_" + mg.Name + " (go)"
+}
+
+// Status 返回所有迁移的执行状态，按版本号排序
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Status
+	for _, mg := range m.migrations {
+		s := Status{Version: mg.Version, Name: mg.Name}
+		if rec, ok := applied[mg.Version]; ok {
+			s.Applied, s.AppliedAt = true, rec.AppliedAt
+		}
+		out = append(out, s)
+	}
+	for version, rec := range applied {
+		if _, ok := m.byVersion[version]; !ok {
+			out = append(out, Status{Version: version, Name: rec.Name, Applied: true, AppliedAt: rec.AppliedAt, Missing: true})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Up 按版本号顺序执行所有未执行的迁移，返回执行的数量
+func (m *Migrator) Up() (int, error) {
+	return m.to("", true)
+}
+
+// Down 按版本号倒序回滚最近执行的 steps 个迁移，返回回滚的数量
+func (m *Migrator) Down(steps int) (int, error) {
+	n := 0
+	err := m.withLock(func() error {
+		applied, err := m.appliedVersions()
+		if err != nil {
+			return err
+		}
+		for i := len(applied) - 1; i >= 0 && n < steps; i-- {
+			if err := m.rollback(applied[i]); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Redo 回滚最近执行的一个迁移并重新执行
+func (m *Migrator) Redo() error {
+	return m.withLock(func() error {
+		applied, err := m.appliedVersions()
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return errors.New("no applied migration to redo")
+		}
+		last := applied[len(applied)-1]
+		if err := m.rollback(last); err != nil {
+			return err
+		}
+		return m.apply(m.byVersion[last])
+	})
+}
+
+// To 迁移到指定版本：执行不晚于该版本的未执行迁移，回滚晚于该版本的已执行迁移。
+// version 为 0 时回滚全部迁移
+func (m *Migrator) To(version string) (int, error) {
+	if version != "0" {
+		if _, ok := m.byVersion[version]; !ok {
+			return 0, fmt.Errorf("unknown migration version %s", version)
+		}
+	}
+	return m.to(version, false)
+}
+
+// to 执行不晚于 target 的迁移（target 为空时不限），upOnly 为 false 时先回滚晚于 target 的迁移
+func (m *Migrator) to(target string, upOnly bool) (int, error) {
+	n := 0
+	err := m.withLock(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+
+		if !upOnly {
+			versions, err := m.appliedVersions()
+			if err != nil {
+				return err
+			}
+			for i := len(versions) - 1; i >= 0 && versions[i] > target; i-- {
+				if err := m.rollback(versions[i]); err != nil {
+					return err
+				}
+				n++
+			}
+		}
+
+		for _, mg := range m.migrations {
+			if target != "" && mg.Version > target {
+				break
+			}
+			if _, ok := applied[mg.Version]; ok {
+				continue
+			}
+			if err := m.apply(mg); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// apply 执行迁移并记录版本，默认二者在同一个事务中
+func (m *Migrator) apply(mg *Migration) error {
+	start := time.Now()
+	run := func(tx *gorm.DB) error {
+		if err := mg.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now()}).Error
+	}
+	if err := m.run(mg, run); err != nil {
+		return fmt.Errorf("migration %s_%s failed: %w", mg.Version, mg.Name, err)
+	}
+	m.Logf("applied   %s_%s (%s)\n", mg.Version, mg.Name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// rollback 回滚迁移并删除版本记录
+func (m *Migrator) rollback(version string) error {
+	mg, ok := m.byVersion[version]
+	if !ok {
+		return fmt.Errorf("cannot roll back %s: migration not found in code or migrations directory", version)
+	}
+	if mg.Down == nil {
+		return fmt.Errorf("cannot roll back %s_%s: %w", mg.Version, mg.Name, ErrIrreversible)
+	}
+
+	start := time.Now()
+	run := func(tx *gorm.DB) error {
+		if err := mg.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, "version = ?", mg.Version).Error
+	}
+	if err := m.run(mg, run); err != nil {
+		return fmt.Errorf("rollback %s_%s failed: %w", mg.Version, mg.Name, err)
+	}
+	m.Logf("reverted  %s_%s (%s)\n", mg.Version, mg.Name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func (m *Migrator) run(mg *Migration, fn func(tx *gorm.DB) error) error {
+	if mg.NoTransaction {
+		return fn(m.db)
+	}
+	return m.db.Transaction(fn)
+}
+
+// applied 返回已执行的迁移记录
+func (m *Migrator) applied() (map[string]SchemaMigration, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	var records []SchemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]SchemaMigration, len(records))
+	for _, r := range records {
+		out[r.Version] = r
+	}
+	return out, nil
+}
+
+// appliedVersions 返回已执行的版本号，按版本号排序
+func (m *Migrator) appliedVersions() ([]string, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]string, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// withLock 持有迁移锁执行 fn
+func (m *Migrator) withLock(fn func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.lockTimeout)
+	defer cancel()
+
+	unlock, err := acquireLock(ctx, m.db)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
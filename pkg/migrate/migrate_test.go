@@ -0,0 +1,371 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func createTable(version, table string) Migration {
+	return Migration{
+		Version: version,
+		Name:    "create_" + table,
+		Up:      func(tx *gorm.DB) error { return tx.Exec("CREATE TABLE " + table + " (id INTEGER)").Error },
+		Down:    func(tx *gorm.DB) error { return tx.Exec("DROP TABLE " + table).Error },
+	}
+}
+
+func appliedVersions(t *testing.T, m *Migrator) []string {
+	t.Helper()
+	versions, err := m.appliedVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return versions
+}
+
+func TestUpDownRedoTo(t *testing.T) {
+	db := openDB(t)
+	m, err := New(db, []Migration{
+		createTable("20260103000000", "c"),
+		createTable("20260101000000", "a"),
+		createTable("20260102000000", "b"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := m.Up(); err != nil || n != 3 {
+		t.Fatalf("up = %d, %v", n, err)
+	}
+	if n, err := m.Up(); err != nil || n != 0 {
+		t.Fatalf("second up = %d, %v", n, err)
+	}
+
+	if n, err := m.Down(2); err != nil || n != 2 {
+		t.Fatalf("down = %d, %v", n, err)
+	}
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []string{"20260101000000"}) {
+		t.Fatalf("after down: %v", got)
+	}
+	if db.Migrator().HasTable("b") || !db.Migrator().HasTable("a") {
+		t.Fatal("down did not drop the latest tables")
+	}
+
+	if n, err := m.To("20260102000000"); err != nil || n != 1 {
+		t.Fatalf("to = %d, %v", n, err)
+	}
+	if err := m.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []string{"20260101000000", "20260102000000"}) {
+		t.Fatalf("after redo: %v", got)
+	}
+
+	if _, err := m.To("0"); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedVersions(t, m); len(got) != 0 {
+		t.Fatalf("after to 0: %v", got)
+	}
+}
+
+// TestFailedMigrationRollsBack 迁移失败时已执行的语句和版本记录一起回滚
+func TestFailedMigrationRollsBack(t *testing.T) {
+	db := openDB(t)
+	m, err := New(db, []Migration{{
+		Version: "20260101000000",
+		Name:    "broken",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE partial (id INTEGER)").Error; err != nil {
+				return err
+			}
+			return errors.New("boom")
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(); err == nil {
+		t.Fatal("expected error")
+	}
+	if db.Migrator().HasTable("partial") || len(appliedVersions(t, m)) != 0 {
+		t.Fatal("failed migration was not rolled back")
+	}
+}
+
+func TestIrreversibleAndMissing(t *testing.T) {
+	db := openDB(t)
+	irreversible := createTable("20260101000000", "a")
+	irreversible.Down = nil
+	m, err := New(db, []Migration{irreversible})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(1); !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("down = %v, want ErrIrreversible", err)
+	}
+
+	// 代码中删除了已执行的迁移
+	db.Create(&SchemaMigration{Version: "20250101000000", Name: "old", AppliedAt: time.Now()})
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Missing || statuses[1].Missing || !statuses[1].Applied {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+}
+
+func TestNewRejectsInvalid(t *testing.T) {
+	db := openDB(t)
+	if _, err := New(db, []Migration{createTable("2026", "a")}); err == nil {
+		t.Fatal("expected error for short version")
+	}
+	if _, err := New(db, []Migration{createTable("20260101000000", "a"), createTable("20260101000000", "b")}); err == nil {
+		t.Fatal("expected error for duplicate version")
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20260101000000_create_items.up.sql":       "CREATE TABLE items (id INTEGER, name TEXT);\nINSERT INTO items VALUES (1, 'a;b');\n",
+		"20260101000000_create_items.down.sql":     "DROP TABLE items;",
+		"20260102000000_add_index.up.sql":          "CREATE INDEX generic ON items (name);",
+		"20260102000000_add_index.up.sqlite.sql":   "-- migrate:no-transaction\nCREATE INDEX idx_items_name ON items (name);",
+		"20260102000000_add_index.up.postgres.sql": "CREATE INDEX CONCURRENTLY idx_items_name ON items (name);",
+		"20260102000000_add_index.down.sql":        "DROP INDEX idx_items_name;",
+		"README.md":                                "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrations, err := LoadDir(dir, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].NoTransaction || !migrations[1].NoTransaction {
+		t.Fatalf("migrations = %+v", migrations)
+	}
+	if filepath.Base(migrations[1].Source) != "20260102000000_add_index.up.sqlite.sql" {
+		t.Fatalf("source = %s, want the sqlite specific file", migrations[1].Source)
+	}
+
+	db := openDB(t)
+	m, err := New(db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	db.Raw("SELECT name FROM items WHERE id = 1").Scan(&name)
+	if name != "a;b" || !db.Migrator().HasIndex("items", "idx_items_name") {
+		t.Fatalf("name = %q, index = %v", name, db.Migrator().HasIndex("items", "idx_items_name"))
+	}
+	if _, err := m.To("0"); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasTable("items") {
+		t.Fatal("items was not dropped")
+	}
+
+	if missing, err := LoadDir(filepath.Join(dir, "missing"), "sqlite"); err != nil || missing != nil {
+		t.Fatalf("missing dir = %v, %v", missing, err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	sql := `-- 注释; 不拆分
+CREATE TABLE t (a TEXT DEFAULT 'x;y', "b;c" INTEGER);
+/* block; comment */
+CREATE FUNCTION f() RETURNS trigger AS $body$
+BEGIN
+  NEW.a := 'z'; RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+SELECT $1;`
+	want := []string{
+		`CREATE TABLE t (a TEXT DEFAULT 'x;y', "b;c" INTEGER)`,
+		"CREATE FUNCTION f() RETURNS trigger AS $body$\nBEGIN\n  NEW.a := 'z'; RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql",
+		"SELECT $1",
+	}
+	if got := SplitStatements(sql); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q", got)
+	}
+}
+
+// TestLock 同一时间只有一个 Migrator 持有锁，超时后返回错误
+func TestLock(t *testing.T) {
+	db := openDB(t)
+	unlock, err := acquireLock(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := acquireLock(ctx, db); err == nil {
+		t.Fatal("second lock acquired while the first is held")
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		second, err := acquireLock(context.Background(), db)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		second()
+	}()
+	time.Sleep(100 * time.Millisecond)
+	unlock()
+	wg.Wait()
+}
+
+func TestCreate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "migrations")
+	paths, err := Create(dir, "Add Users-Index", time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "20261018093000_add_users_index.up.sql"),
+		filepath.Join(dir, "20261018093000_add_users_index.down.sql"),
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("paths = %v", paths)
+	}
+	if _, err := LoadDir(dir, "sqlite"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type itemV1 struct {
+	ID     uint   `gorm:"primarykey"`
+	Name   string `gorm:"size:50;index"`
+	Legacy string
+}
+
+func (itemV1) TableName() string { return "items" }
+
+type itemV2 struct {
+	ID    uint   `gorm:"primarykey"`
+	Name  string `gorm:"size:50;uniqueIndex:idx_items_name"`
+	Price int    `gorm:"not null;default:0"`
+}
+
+func (itemV2) TableName() string { return "items" }
+
+type tag struct {
+	ID   uint   `gorm:"primarykey"`
+	Name string `gorm:"size:20;uniqueIndex"`
+}
+
+// TestDiff 生成的迁移执行后结构与模型一致，回滚后恢复原来的结构
+func TestDiff(t *testing.T) {
+	db := openDB(t)
+	if err := db.AutoMigrate(&itemV1{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("CREATE TABLE orphans (id INTEGER PRIMARY KEY, note TEXT NOT NULL DEFAULT 'x')")
+	db.Exec("INSERT INTO items (name, legacy) VALUES ('a', 'old')")
+
+	changes, err := Diff(db, []any{&itemV2{}, &tag{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	destructive := map[string]bool{}
+	for _, c := range changes {
+		destructive[c.Description] = c.Destructive
+	}
+	want := map[string]bool{
+		"drop index idx_items_name on items":   false,
+		"create index idx_items_name on items": false,
+		"add column items.price":               false,
+		"drop column items.legacy":             true,
+		"create table tags":                    false,
+		"drop table orphans":                   true,
+	}
+	if !reflect.DeepEqual(destructive, want) {
+		t.Fatalf("changes = %v", destructive)
+	}
+
+	dir := t.TempDir()
+	if _, err := WriteDiff(dir, "update items", "sqlite", changes, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := LoadDir(dir, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := Diff(db, []any{&itemV2{}, &tag{}}); err != nil || len(changes) != 0 {
+		t.Fatalf("after up: %+v, %v", changes, err)
+	}
+
+	if _, err := m.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	if !db.Migrator().HasTable("orphans") || db.Migrator().HasTable("tags") {
+		t.Fatal("down did not restore the tables")
+	}
+	// items 恢复为 itemV1 的结构，orphans 不是模型，仍然会被报告
+	if changes, err := Diff(db, []any{&itemV1{}}); err != nil || len(changes) != 1 || changes[0].Description != "drop table orphans" {
+		t.Fatalf("after down: %+v, %v", changes, err)
+	}
+}
+
+func TestDiffFlagsSQLiteAlter(t *testing.T) {
+	db := openDB(t)
+	db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, price TEXT)")
+
+	changes, err := Diff(db, []any{&itemV2{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range changes {
+		if c.Description == "alter column items.price type text -> integer not null false -> true" {
+			if !c.Manual || len(c.Up) != 0 {
+				t.Fatalf("change = %+v", c)
+			}
+			return
+		}
+	}
+	t.Fatalf("alter not reported: %+v", changes)
+}
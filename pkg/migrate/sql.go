@@ -0,0 +1,257 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// noTransactionDirective 出现在 SQL 文件中时，该文件不在事务中执行
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// sqlFilePattern 匹配 <version>_<name>.<up|down>[.<dialect>].sql
+var sqlFilePattern = regexp.MustCompile(`^(\d{14})_([a-z0-9_]+)\.(up|down)(?:\.(mysql|postgres|sqlite))?\.sql$`)
+
+// nameSeparator 迁移名称中非小写字母和数字的部分替换为下划线
+var nameSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sqlFile 迁移目录中的单个 SQL 文件
+type sqlFile struct {
+	path          string
+	statements    []string
+	noTransaction bool
+}
+
+// LoadDir 读取迁移目录中的 SQL 迁移。同一个版本可以有通用文件和指定数据库的文件，
+// 如 20261018093000_add_index.up.sql 和 20261018093000_add_index.up.postgres.sql，
+// 后者优先；其他数据库的文件被忽略。目录不存在时返回空
+func LoadDir(dir, dialect string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	type pair struct {
+		name                     string
+		file                     string // 第一个文件名，用于报告版本冲突
+		up, down                 *sqlFile
+		upSpecific, downSpecific bool
+	}
+	byVersion := make(map[string]*pair)
+	var order []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := sqlFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s, expected <version>_<name>.<up|down>[.<dialect>].sql", entry.Name())
+		}
+		version, name, direction, fileDialect := match[1], match[2], match[3], match[4]
+		if fileDialect != "" && fileDialect != dialect {
+			continue
+		}
+
+		p, ok := byVersion[version]
+		if !ok {
+			p = &pair{name: name, file: entry.Name()}
+			byVersion[version] = p
+			order = append(order, version)
+		} else if p.name != name {
+			return nil, fmt.Errorf("migration files %s and %s share version %s", p.file, entry.Name(), version)
+		}
+
+		// 指定数据库的文件优先于通用文件
+		specific := fileDialect != ""
+		current, currentSpecific := &p.up, &p.upSpecific
+		if direction == "down" {
+			current, currentSpecific = &p.down, &p.downSpecific
+		}
+		if *current != nil && (*currentSpecific || !specific) {
+			continue
+		}
+
+		f, err := readSQLFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		*current, *currentSpecific = f, specific
+	}
+
+	migrations := make([]Migration, 0, len(order))
+	for _, version := range order {
+		p := byVersion[version]
+		if p.up == nil {
+			return nil, fmt.Errorf("migration %s_%s has no up file", version, p.name)
+		}
+		mg := Migration{
+			Version:       version,
+			Name:          p.name,
+			Up:            execStatements(p.up.statements),
+			NoTransaction: p.up.noTransaction,
+			Source:        p.up.path,
+		}
+		if p.down != nil {
+			mg.Down = execStatements(p.down.statements)
+			mg.NoTransaction = mg.NoTransaction || p.down.noTransaction
+		}
+		migrations = append(migrations, mg)
+	}
+	return migrations, nil
+}
+
+func readSQLFile(path string) (*sqlFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration: %w", err)
+	}
+	content := string(data)
+	f := &sqlFile{path: path, statements: SplitStatements(content)}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == noTransactionDirective {
+			f.noTransaction = true
+			break
+		}
+	}
+	return f, nil
+}
+
+// execStatements 依次执行 SQL 语句。多数驱动不支持一次执行多条语句，因此逐条执行
+func execStatements(statements []string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("%w\n%s", err, stmt)
+			}
+		}
+		return nil
+	}
+}
+
+// SplitStatements 按分号拆分 SQL 语句，跳过注释，引号、反引号和 $$ 中的分号不拆分
+func SplitStatements(content string) []string {
+	var (
+		out   []string
+		buf   strings.Builder
+		i     int
+		n     = len(content)
+		flush = func() {
+			if stmt := strings.TrimSpace(buf.String()); stmt != "" {
+				out = append(out, stmt)
+			}
+			buf.Reset()
+		}
+	)
+
+	for i < n {
+		c := content[i]
+		switch {
+		case c == '-' && i+1 < n && content[i+1] == '-':
+			// 行注释
+			for i < n && content[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < n && content[i+1] == '*':
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = n
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for j < n {
+				if content[j] == c {
+					// 连续两个引号是转义
+					if j+1 < n && content[j+1] == c {
+						j += 2
+						continue
+					}
+					break
+				}
+				if content[j] == '\\' && c == '\'' {
+					j++
+				}
+				j++
+			}
+			end := min(j+1, n)
+			buf.WriteString(content[i:end])
+			i = end
+		case c == '$':
+			tag := dollarTag(content[i:])
+			if tag == "" {
+				buf.WriteByte(c)
+				i++
+				continue
+			}
+			end := strings.Index(content[i+len(tag):], tag)
+			if end < 0 {
+				buf.WriteString(content[i:])
+				i = n
+				continue
+			}
+			stop := i + len(tag) + end + len(tag)
+			buf.WriteString(content[i:stop])
+			i = stop
+		case c == ';':
+			flush()
+			i++
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+	return out
+}
+
+// dollarTag 返回 PostgreSQL 的 $$ 或 $tag$ 引号，不是引号时返回空
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		c := s[j]
+		if c == '$' {
+			return s[:j+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || j > 1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
+
+// Create 在迁移目录中创建一对空的 SQL 迁移文件，返回文件路径
+func Create(dir, name string, now time.Time) ([]string, error) {
+	name = strings.Trim(nameSeparator.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("migration name is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	version := now.UTC().Format("20060102150405")
+	contents := map[string]string{
+		"up":   "-- " + version + "_" + name + "\n",
+		"down": "-- 回滚 " + version + "_" + name + "\n",
+	}
+	var paths []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+		if err := writeNew(path, contents[direction]); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,240 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"evaframe/pkg/config"
+	"evaframe/pkg/helpers"
+	ejwt "evaframe/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// MockProviderName 模拟提供方在注册表中的名称
+	MockProviderName = "mock"
+	// MockPath 模拟提供方挂载的路径
+	MockPath = "/mock-oidc"
+
+	mockClientID    = "evaframe-mock"
+	mockKID         = "mock-1"
+	mockCodeTTL     = time.Minute
+	mockIDTokenTTL  = 5 * time.Minute
+	mockDefaultMail = "mock.user@example.com"
+)
+
+// mockCode 已签发、尚未兑换的授权码
+type mockCode struct {
+	email       string
+	nonce       string
+	challenge   string
+	redirectURI string
+	expiresAt   time.Time
+}
+
+// MockIssuer 内置的模拟 OIDC 提供方，授权端点不显示登录页而是直接以
+// login_hint（或配置的默认邮箱）登录并跳转回客户端，仅用于开发和测试
+type MockIssuer struct {
+	issuer       string
+	clientSecret string
+	defaultEmail string
+	key          *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*mockCode
+}
+
+// NewMockIssuer 创建模拟提供方 Provider，未启用时返回 nil。模拟提供方不校验凭据，
+// 任何人都可以用任意邮箱登录，因此 release 模式下拒绝启用
+func NewMockIssuer(cfg *config.Config) (*MockIssuer, error) {
+	if !cfg.OIDC.Mock.Enabled {
+		return nil, nil
+	}
+	if cfg.Server.Mode == "release" {
+		return nil, errors.New("oidc.mock.enabled must not be set when server.mode is release")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := helpers.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	email := cfg.OIDC.Mock.Email
+	if email == "" {
+		email = mockDefaultMail
+	}
+
+	return &MockIssuer{
+		issuer:       cfg.BaseURL() + MockPath,
+		clientSecret: secret,
+		defaultEmail: email,
+		key:          key,
+		codes:        make(map[string]*mockCode),
+	}, nil
+}
+
+// ProviderConfig 返回连接模拟提供方所需的客户端配置
+func (m *MockIssuer) ProviderConfig() config.OIDCProvider {
+	return config.OIDCProvider{
+		Name:         MockProviderName,
+		Issuer:       m.issuer,
+		ClientID:     mockClientID,
+		ClientSecret: m.clientSecret,
+	}
+}
+
+// RegisterRoutes 注册模拟提供方的端点
+func (m *MockIssuer) RegisterRoutes(router gin.IRouter) {
+	group := router.Group(MockPath)
+	{
+		group.GET("/.well-known/openid-configuration", m.discovery)
+		group.GET("/jwks", m.jwks)
+		group.GET("/authorize", m.authorize)
+		group.POST("/token", m.token)
+	}
+}
+
+func (m *MockIssuer) discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, Discovery{
+		Issuer:                m.issuer,
+		AuthorizationEndpoint: m.issuer + "/authorize",
+		TokenEndpoint:         m.issuer + "/token",
+		JWKSURI:               m.issuer + "/jwks",
+	})
+}
+
+func (m *MockIssuer) jwks(c *gin.Context) {
+	jwk, err := ejwt.PublicJWK(mockKID, "RS256", m.key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, ejwt.JWKS{Keys: []ejwt.JWK{jwk}})
+}
+
+func (m *MockIssuer) authorize(c *gin.Context) {
+	redirectURI := c.Query("redirect_uri")
+	if c.Query("client_id") != mockClientID || redirectURI == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "unknown client_id or missing redirect_uri"})
+		return
+	}
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "invalid redirect_uri"})
+		return
+	}
+
+	// 参数错误时按规范带上 error 跳转回客户端
+	q := target.Query()
+	q.Set("state", c.Query("state"))
+	switch {
+	case c.Query("response_type") != "code":
+		q.Set("error", "unsupported_response_type")
+	case c.Query("code_challenge") == "" || c.Query("code_challenge_method") != "S256":
+		q.Set("error", "invalid_request")
+		q.Set("error_description", "PKCE with S256 is required")
+	default:
+		code, err := helpers.RandomToken(32)
+		if err != nil {
+			q.Set("error", "server_error")
+			break
+		}
+		email := c.Query("login_hint")
+		if email == "" {
+			email = m.defaultEmail
+		}
+
+		m.mu.Lock()
+		for key, pending := range m.codes {
+			if time.Now().After(pending.expiresAt) {
+				delete(m.codes, key)
+			}
+		}
+		m.codes[code] = &mockCode{
+			email:       email,
+			nonce:       c.Query("nonce"),
+			challenge:   c.Query("code_challenge"),
+			redirectURI: redirectURI,
+			expiresAt:   time.Now().Add(mockCodeTTL),
+		}
+		m.mu.Unlock()
+		q.Set("code", code)
+	}
+
+	target.RawQuery = q.Encode()
+	c.Redirect(http.StatusFound, target.String())
+}
+
+func (m *MockIssuer) token(c *gin.Context) {
+	clientID, clientSecret, ok := c.Request.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID != mockClientID || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(m.clientSecret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client"})
+		return
+	}
+	if c.PostForm("grant_type") != "authorization_code" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// 授权码只能兑换一次
+	m.mu.Lock()
+	code, ok := m.codes[c.PostForm("code")]
+	delete(m.codes, c.PostForm("code"))
+	m.mu.Unlock()
+
+	if !ok || time.Now().After(code.expiresAt) || code.redirectURI != c.PostForm("redirect_uri") ||
+		S256Challenge(c.PostForm("code_verifier")) != code.challenge {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            m.issuer,
+		"sub":            "mock-" + helpers.SHA256Hex(strings.ToLower(code.email))[:16],
+		"aud":            mockClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(mockIDTokenTTL).Unix(),
+		"nonce":          code.nonce,
+		"email":          code.email,
+		"email_verified": true,
+		"name":           strings.Split(code.email, "@")[0],
+	})
+	idToken.Header["kid"] = mockKID
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	accessToken, err := helpers.RandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": accessToken,
+		"id_token":     signed,
+		"token_type":   "Bearer",
+		"expires_in":   int(mockIDTokenTTL.Seconds()),
+	})
+}
@@ -0,0 +1,111 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"evaframe/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newMockProvider 启动模拟提供方，返回连接它的客户端
+func newMockProvider(t *testing.T) *Provider {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	var cfg config.Config
+	cfg.Server.PublicURL = server.URL
+	cfg.OIDC.Mock.Enabled = true
+	mock, err := NewMockIssuer(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.RegisterRoutes(router)
+
+	registry, err := NewRegistry(&cfg, mock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := registry.Get(MockProviderName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// authorize 访问授权端点，返回回调地址中的授权码
+func authorize(t *testing.T, p *Provider, state, nonce, verifier string) string {
+	t.Helper()
+
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, S256Challenge(verifier), "t@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Query().Get("state"); got != state {
+		t.Fatalf("state = %q, want %q", got, state)
+	}
+	return location.Query().Get("code")
+}
+
+func TestAuthorizationCodeFlowWithMockIssuer(t *testing.T) {
+	p := newMockProvider(t)
+	verifier, err := NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := authorize(t, p, "state-1", "nonce-1", verifier)
+	claims, err := p.Exchange(context.Background(), code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Email != "t@example.com" || !claims.EmailVerified || claims.Subject == "" {
+		t.Fatalf("claims = %+v", claims)
+	}
+
+	// 授权码只能兑换一次
+	if _, err := p.Exchange(context.Background(), code, verifier, "nonce-1"); err == nil {
+		t.Fatal("authorization code was accepted twice")
+	}
+}
+
+func TestExchangeRejectsWrongVerifierAndNonce(t *testing.T) {
+	p := newMockProvider(t)
+	verifier, err := NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := authorize(t, p, "state-1", "nonce-1", verifier)
+	if _, err := p.Exchange(context.Background(), code, "wrong-verifier", "nonce-1"); err == nil {
+		t.Fatal("exchange succeeded with a wrong code_verifier")
+	}
+
+	code = authorize(t, p, "state-2", "nonce-2", verifier)
+	if _, err := p.Exchange(context.Background(), code, verifier, "other-nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+	}
+}
@@ -0,0 +1,91 @@
+package oidc
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MaxPendingLogins 同时保存的登录上下文上限，超出时丢弃最早的记录
+//
+// 发起登录的接口无需认证，上限防止大量请求使内存无限增长
+const MaxPendingLogins = 10000
+
+// PendingLogin 跳转到提供方之前保存的登录上下文，回调时按 state 取回
+type PendingLogin struct {
+	Provider  string
+	Nonce     string
+	Verifier  string
+	ExpiresAt time.Time
+}
+
+// pendingEntry 按保存顺序排列的登录上下文
+type pendingEntry struct {
+	state string
+	login *PendingLogin
+}
+
+// StateStore 基于内存的登录上下文存储，仅适用于单实例部署
+type StateStore struct {
+	mu      sync.Mutex
+	limit   int
+	pending map[string]*list.Element
+	order   *list.List // 按保存顺序排列，最早的在前
+}
+
+// NewStateStore 创建登录上下文存储 Provider
+func NewStateStore() *StateStore {
+	return newStateStore(MaxPendingLogins)
+}
+
+func newStateStore(limit int) *StateStore {
+	return &StateStore{
+		limit:   limit,
+		pending: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Save 保存登录上下文
+func (s *StateStore) Save(state string, login *PendingLogin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.pending[state]; ok {
+		s.remove(el)
+	}
+
+	// 有效期相同，最早保存的最先过期，只需从头清理到第一条未过期的记录
+	now := time.Now()
+	for el := s.order.Front(); el != nil; el = s.order.Front() {
+		if !now.After(el.Value.(*pendingEntry).login.ExpiresAt) && s.order.Len() < s.limit {
+			break
+		}
+		s.remove(el)
+	}
+
+	s.pending[state] = s.order.PushBack(&pendingEntry{state: state, login: login})
+}
+
+// Take 取回并删除登录上下文，每个 state 只能使用一次
+func (s *StateStore) Take(state string) (*PendingLogin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.pending[state]
+	if !ok {
+		return nil, false
+	}
+	s.remove(el)
+
+	login := el.Value.(*pendingEntry).login
+	if time.Now().After(login.ExpiresAt) {
+		return nil, false
+	}
+	return login, true
+}
+
+func (s *StateStore) remove(el *list.Element) {
+	delete(s.pending, el.Value.(*pendingEntry).state)
+	s.order.Remove(el)
+}
@@ -0,0 +1,58 @@
+package oidc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func pending(ttl time.Duration) *PendingLogin {
+	return &PendingLogin{Provider: "mock", ExpiresAt: time.Now().Add(ttl)}
+}
+
+func TestStateStoreTakeOnce(t *testing.T) {
+	s := NewStateStore()
+	s.Save("state", pending(time.Minute))
+
+	if _, ok := s.Take("state"); !ok {
+		t.Fatal("saved login not found")
+	}
+	if _, ok := s.Take("state"); ok {
+		t.Fatal("state used twice")
+	}
+	if _, ok := s.Take("unknown"); ok {
+		t.Fatal("unknown state accepted")
+	}
+}
+
+func TestStateStorePrunesExpired(t *testing.T) {
+	s := NewStateStore()
+	s.Save("expired", pending(-time.Second))
+	s.Save("other", pending(-time.Second))
+	if _, ok := s.Take("expired"); ok {
+		t.Fatal("expired login accepted")
+	}
+
+	s.Save("active", pending(time.Minute))
+	if len(s.pending) != 1 || s.order.Len() != 1 {
+		t.Fatalf("%d pending logins after pruning, want 1", len(s.pending))
+	}
+}
+
+// TestStateStoreLimit 超出上限时丢弃最早的登录上下文
+func TestStateStoreLimit(t *testing.T) {
+	s := newStateStore(3)
+	for i := range 5 {
+		s.Save(fmt.Sprintf("state-%d", i), pending(time.Minute))
+	}
+
+	if len(s.pending) != 3 || s.order.Len() != 3 {
+		t.Fatalf("%d pending logins, want 3", len(s.pending))
+	}
+	for i := range 5 {
+		_, ok := s.Take(fmt.Sprintf("state-%d", i))
+		if want := i >= 2; ok != want {
+			t.Fatalf("state-%d found = %v, want %v", i, ok, want)
+		}
+	}
+}
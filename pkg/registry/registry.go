@@ -0,0 +1,57 @@
+// Package registry GORM 模型注册表。定义模型的包在 init 中调用 RegisterModel，
+// migrate diff、seeders 以及管理后台、OpenAPI 等工具都从这里读取模型，不再各自维护模型列表
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Model 已注册的模型
+type Model struct {
+	Name  string // 包名加类型名，如 models.User
+	Value any    // 指向零值的指针，可以直接传给 GORM
+}
+
+var (
+	mu     sync.RWMutex
+	models []Model
+	byType = make(map[reflect.Type]bool)
+)
+
+// RegisterModel 注册模型，参数必须是结构体指针，如 &User{}。
+// 只在 init 中调用，重复注册同一类型时 panic
+func RegisterModel(values ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, value := range values {
+		t := reflect.TypeOf(value)
+		if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+			panic(fmt.Sprintf("registry: model must be a pointer to a struct, got %T", value))
+		}
+		if byType[t.Elem()] {
+			panic(fmt.Sprintf("registry: model %s registered twice", t.Elem()))
+		}
+		byType[t.Elem()] = true
+		models = append(models, Model{Name: t.Elem().String(), Value: reflect.New(t.Elem()).Interface()})
+	}
+}
+
+// Models 返回已注册的模型，按注册顺序排列
+func Models() []Model {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Model(nil), models...)
+}
+
+// Values 返回已注册模型的零值指针，可以直接传给 AutoMigrate 或 migrate.Diff
+func Values() []any {
+	mu.RLock()
+	defer mu.RUnlock()
+	values := make([]any, 0, len(models))
+	for _, m := range models {
+		values = append(values, m.Value)
+	}
+	return values
+}
@@ -0,0 +1,30 @@
+package registry
+
+import "testing"
+
+type widget struct{ ID uint }
+
+type gadget struct{ ID uint }
+
+func TestRegisterModel(t *testing.T) {
+	RegisterModel(&widget{}, &gadget{})
+
+	got := Models()
+	if len(got) != 2 || got[0].Name != "registry.widget" || got[1].Name != "registry.gadget" {
+		t.Fatalf("models = %+v", got)
+	}
+	if _, ok := Values()[0].(*widget); !ok {
+		t.Fatalf("value = %T", Values()[0])
+	}
+
+	for name, value := range map[string]any{"duplicate": &widget{}, "not a pointer": gadget{}, "nil": nil} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			RegisterModel(value)
+		}()
+	}
+}
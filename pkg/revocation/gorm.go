@@ -0,0 +1,52 @@
+package revocation
+
+import (
+	"time"
+
+	"evaframe/pkg/registry"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func init() {
+	registry.RegisterModel(&RevokedToken{})
+}
+
+// RevokedToken 已吊销的令牌记录
+type RevokedToken struct {
+	JTI       string    `gorm:"primarykey;size:64" json:"jti"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GormStore 基于数据库的吊销存储，多实例部署时共享吊销记录
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore 创建数据库吊销存储
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Revoke(jti string, expiresAt time.Time) error {
+	// 顺便清理已过期的记录
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&RevokedToken{}).Error; err != nil {
+		return err
+	}
+
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&RevokedToken{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (s *GormStore) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := s.db.Model(&RevokedToken{}).Where("jti = ? AND expires_at >= ?", jti, time.Now()).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
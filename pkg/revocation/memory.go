@@ -0,0 +1,44 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore 基于内存的吊销存储，仅适用于单实例部署，重启后记录丢失
+type MemoryStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+// NewMemoryStore 创建内存吊销存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryStore) Revoke(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 顺便清理已过期的记录
+	now := time.Now()
+	for id, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, id)
+		}
+	}
+
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryStore) IsRevoked(jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// 过期的记录等待下次 Revoke 时清理，在此之前不再报告
+	exp, ok := s.revoked[jti]
+	return ok && !time.Now().After(exp), nil
+}
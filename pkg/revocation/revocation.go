@@ -0,0 +1,38 @@
+// Package revocation 提供访问令牌的吊销（黑名单）存储
+//
+// 访问令牌是无状态的 JWT，签发后在过期前始终有效。
+// 吊销存储记录被主动作废的令牌 ID（jti），认证中间件据此拒绝这些令牌。
+// 记录只需保留到令牌本身过期为止。
+package revocation
+
+import (
+	"fmt"
+	"time"
+
+	"evaframe/pkg/config"
+
+	"github.com/google/wire"
+	"gorm.io/gorm"
+)
+
+var ProviderSet = wire.NewSet(NewStore)
+
+// Store 令牌吊销存储接口
+type Store interface {
+	// Revoke 吊销令牌，expiresAt 为令牌本身的过期时间，之后记录可被清理
+	Revoke(jti string, expiresAt time.Time) error
+	// IsRevoked 判断令牌是否已被吊销
+	IsRevoked(jti string) (bool, error)
+}
+
+// NewStore 根据配置创建吊销存储 Provider
+func NewStore(cfg *config.Config, db *gorm.DB) (Store, error) {
+	switch cfg.JWT.RevocationStore {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "gorm":
+		return NewGormStore(db), nil
+	default:
+		return nil, fmt.Errorf("不支持的令牌吊销存储: %s", cfg.JWT.RevocationStore)
+	}
+}
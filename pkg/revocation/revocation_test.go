@@ -0,0 +1,102 @@
+package revocation
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "revocation.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&RevokedToken{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestStores 返回各存储实现，以及统计其中记录数的函数，用于确认过期记录被清理
+func newTestStores(t *testing.T) map[string]struct {
+	store Store
+	count func() int
+} {
+	memory := NewMemoryStore()
+	db := newTestDB(t)
+	return map[string]struct {
+		store Store
+		count func() int
+	}{
+		"memory": {memory, func() int {
+			memory.mu.RLock()
+			defer memory.mu.RUnlock()
+			return len(memory.revoked)
+		}},
+		"gorm": {NewGormStore(db), func() int {
+			var count int64
+			if err := db.Model(&RevokedToken{}).Count(&count).Error; err != nil {
+				t.Fatal(err)
+			}
+			return int(count)
+		}},
+	}
+}
+
+func assertRevoked(t *testing.T, store Store, jti string, want bool) {
+	t.Helper()
+	revoked, err := store.IsRevoked(jti)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked != want {
+		t.Fatalf("IsRevoked(%s) = %v, want %v", jti, revoked, want)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	for name, tt := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			expiresAt := time.Now().Add(time.Hour)
+			if err := tt.store.Revoke("a", expiresAt); err != nil {
+				t.Fatal(err)
+			}
+			assertRevoked(t, tt.store, "a", true)
+			assertRevoked(t, tt.store, "b", false)
+
+			// 重复吊销同一个令牌
+			if err := tt.store.Revoke("a", expiresAt); err != nil {
+				t.Fatal(err)
+			}
+			assertRevoked(t, tt.store, "a", true)
+			if n := tt.count(); n != 1 {
+				t.Fatalf("%d records, want 1", n)
+			}
+		})
+	}
+}
+
+// TestExpiredEntriesArePruned 令牌过期后不再报告为已吊销，记录在下次吊销时被清理
+func TestExpiredEntriesArePruned(t *testing.T) {
+	for name, tt := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := tt.store.Revoke("expired", time.Now().Add(-time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			assertRevoked(t, tt.store, "expired", false)
+
+			if err := tt.store.Revoke("active", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			if n := tt.count(); n != 1 {
+				t.Fatalf("%d records after pruning, want 1", n)
+			}
+			assertRevoked(t, tt.store, "active", true)
+		})
+	}
+}
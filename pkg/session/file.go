@@ -0,0 +1,170 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore 每个会话保存为目录下的一个 JSON 文件，适用于单机部署且需要在重启后保留会话的场景
+type FileStore struct {
+	mu  sync.Mutex // 删除与续期互斥，避免续期把刚删除的会话写回
+	dir string
+}
+
+// NewFileStore 创建文件会话存储，目录不存在时自动创建
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path 会话 ID 为十六进制哈希，拒绝其他格式，防止路径穿越
+func (s *FileStore) path(id string) (string, bool) {
+	if id == "" || strings.Trim(id, "0123456789abcdef") != "" {
+		return "", false
+	}
+	return filepath.Join(s.dir, id+".json"), true
+}
+
+func (s *FileStore) Get(id string) (*Session, error) {
+	path, ok := s.path(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	sess, err := readSessionFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(sess.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return sess, nil
+}
+
+func (s *FileStore) Save(sess *Session) error {
+	path, ok := s.path(sess.ID)
+	if !ok {
+		return errors.New("invalid session id")
+	}
+	return s.write(path, sess)
+}
+
+// Touch 与 Delete 持有同一把锁，读取、修改和写回之间会话不会被删除
+func (s *FileStore) Touch(id string, lastSeenAt, expiresAt time.Time) error {
+	path, ok := s.path(id)
+	if !ok {
+		return ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, err := readSessionFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	sess.LastSeenAt = lastSeenAt
+	sess.ExpiresAt = expiresAt
+	return s.write(path, sess)
+}
+
+func (s *FileStore) write(path string, sess *Session) error {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免并发读取到写了一半的文件
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileStore) Delete(id string) error {
+	path, ok := s.path(id)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) DeleteByUser(userID uint, exceptID string) error {
+	return s.deleteWhere(func(sess *Session) bool {
+		return sess.UserID == userID && sess.ID != exceptID
+	})
+}
+
+func (s *FileStore) DeleteExpired(now time.Time) error {
+	return s.deleteWhere(func(sess *Session) bool {
+		return now.After(sess.ExpiresAt)
+	})
+}
+
+// deleteWhere 遍历目录删除满足条件的会话，同一时间只允许一次遍历
+func (s *FileStore) deleteWhere(match func(sess *Session) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		sess, err := readSessionFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil || match(sess) {
+			// 无法解析的文件同样删除
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readSessionFile(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
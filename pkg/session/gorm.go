@@ -0,0 +1,59 @@
+package session
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore 基于数据库的会话存储，多实例部署时共享会话
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore 创建数据库会话存储
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Get(id string) (*Session, error) {
+	var sess Session
+	err := s.db.Where("id = ? AND expires_at > ?", id, time.Now()).First(&sess).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+func (s *GormStore) Save(sess *Session) error {
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(sess).Error
+}
+
+func (s *GormStore) Touch(id string, lastSeenAt, expiresAt time.Time) error {
+	res := s.db.Model(&Session{}).Where("id = ?", id).
+		Updates(map[string]any{"last_seen_at": lastSeenAt, "expires_at": expiresAt})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *GormStore) Delete(id string) error {
+	return s.db.Where("id = ?", id).Delete(&Session{}).Error
+}
+
+func (s *GormStore) DeleteByUser(userID uint, exceptID string) error {
+	return s.db.Where("user_id = ? AND id <> ?", userID, exceptID).Delete(&Session{}).Error
+}
+
+func (s *GormStore) DeleteExpired(now time.Time) error {
+	return s.db.Where("expires_at < ?", now).Delete(&Session{}).Error
+}
@@ -0,0 +1,247 @@
+package session
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"evaframe/pkg/config"
+	"evaframe/pkg/helpers"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultCookieName  = "evaframe_session"
+	defaultIdleTimeout = 30 * time.Minute
+	defaultLifetime    = 24 * time.Hour
+	defaultCSRFHeader  = "X-CSRF-Token"
+
+	// touchInterval 距上次续期超过该时长才写回存储，避免每个请求都写一次
+	touchInterval = time.Minute
+)
+
+// Identity 创建会话时记录的用户身份
+type Identity struct {
+	UserID      uint
+	Email       string
+	Roles       []string
+	Permissions []string
+}
+
+// Manager 负责会话的创建、校验、续期以及 Cookie 的读写
+type Manager struct {
+	store       Store
+	enabled     bool
+	cookieName  string
+	domain      string
+	path        string
+	secure      bool
+	sameSite    http.SameSite
+	idleTimeout time.Duration
+	lifetime    time.Duration
+	csrfHeader  string
+}
+
+// NewManager 根据配置创建会话管理器，auth.mode 不是 session 时 Enabled 返回 false
+func NewManager(cfg *config.Config, store Store) (*Manager, error) {
+	switch cfg.Auth.Mode {
+	case "", "jwt", "session":
+	default:
+		return nil, fmt.Errorf("不支持的登录方式: %s", cfg.Auth.Mode)
+	}
+
+	c := cfg.Session
+	m := &Manager{
+		store:       store,
+		enabled:     cfg.Auth.Mode == "session",
+		cookieName:  c.CookieName,
+		domain:      c.Domain,
+		path:        c.Path,
+		idleTimeout: c.IdleTimeout,
+		lifetime:    c.Lifetime,
+		csrfHeader:  c.CSRFHeader,
+	}
+	if m.cookieName == "" {
+		m.cookieName = defaultCookieName
+	}
+	if m.path == "" {
+		m.path = "/"
+	}
+	if m.idleTimeout <= 0 {
+		m.idleTimeout = defaultIdleTimeout
+	}
+	if m.lifetime <= 0 {
+		m.lifetime = defaultLifetime
+	}
+	if m.csrfHeader == "" {
+		m.csrfHeader = defaultCSRFHeader
+	}
+
+	// 未显式配置时，对外地址为 https 则只通过 HTTPS 发送 Cookie
+	if c.Secure != nil {
+		m.secure = *c.Secure
+	} else {
+		m.secure = strings.HasPrefix(cfg.BaseURL(), "https://")
+	}
+
+	switch strings.ToLower(c.SameSite) {
+	case "", "lax":
+		m.sameSite = http.SameSiteLaxMode
+	case "strict":
+		m.sameSite = http.SameSiteStrictMode
+	case "none":
+		// 浏览器会丢弃没有 Secure 属性的 SameSite=None Cookie
+		if !m.secure {
+			return nil, errors.New("session.same_site 为 none 时必须启用 session.secure")
+		}
+		m.sameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("不支持的 SameSite 取值: %s", c.SameSite)
+	}
+
+	return m, nil
+}
+
+// Enabled 是否使用服务端会话登录
+func (m *Manager) Enabled() bool {
+	return m != nil && m.enabled
+}
+
+// CSRFHeader 携带 CSRF 令牌的请求头名称
+func (m *Manager) CSRFHeader() string {
+	return m.csrfHeader
+}
+
+// Create 创建新会话，返回会话和写入 Cookie 的令牌；会话 ID 总是由服务端重新生成
+func (m *Manager) Create(identity Identity) (*Session, string, error) {
+	token, err := helpers.RandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	csrf, err := helpers.RandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	sess := &Session{
+		ID:          helpers.SHA256Hex(token),
+		UserID:      identity.UserID,
+		Email:       identity.Email,
+		Roles:       identity.Roles,
+		Permissions: identity.Permissions,
+		CSRFToken:   csrf,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+		ExpiresAt:   m.expiresAt(now, now),
+	}
+
+	// 顺便清理已过期的会话
+	if err := m.store.DeleteExpired(now); err != nil {
+		return nil, "", err
+	}
+	if err := m.store.Save(sess); err != nil {
+		return nil, "", err
+	}
+	return sess, token, nil
+}
+
+// Load 根据 Cookie 中的令牌加载会话并按空闲超时续期，无效或过期时返回 ErrNotFound
+func (m *Manager) Load(token string) (*Session, error) {
+	if token == "" {
+		return nil, ErrNotFound
+	}
+	sess, err := m.store.Get(helpers.SHA256Hex(token))
+	if err != nil {
+		return nil, err
+	}
+
+	// 续期不能用 Save，否则与登出、修改密码等并发时会把刚删除的会话写回去
+	now := time.Now()
+	if now.Sub(sess.LastSeenAt) >= touchInterval {
+		sess.LastSeenAt = now
+		sess.ExpiresAt = m.expiresAt(sess.CreatedAt, now)
+		if err := m.store.Touch(sess.ID, sess.LastSeenAt, sess.ExpiresAt); err != nil {
+			return nil, err
+		}
+	}
+	return sess, nil
+}
+
+// Get 按会话 ID 返回会话，不续期
+func (m *Manager) Get(id string) (*Session, error) {
+	return m.store.Get(id)
+}
+
+// Destroy 删除会话
+func (m *Manager) Destroy(id string) error {
+	return m.store.Delete(id)
+}
+
+// DestroyToken 删除 Cookie 令牌对应的会话
+func (m *Manager) DestroyToken(token string) error {
+	if token == "" {
+		return nil
+	}
+	return m.store.Delete(helpers.SHA256Hex(token))
+}
+
+// DestroyUser 删除用户的所有会话，exceptID 不为空时保留该会话
+func (m *Manager) DestroyUser(userID uint, exceptID string) error {
+	return m.store.DeleteByUser(userID, exceptID)
+}
+
+// VerifyCSRF 校验请求头中的 CSRF 令牌，安全方法（GET/HEAD/OPTIONS/TRACE）不需要校验
+func (m *Manager) VerifyCSRF(c *gin.Context, sess *Session) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	token := c.GetHeader(m.csrfHeader)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(sess.CSRFToken)) == 1
+}
+
+// Token 读取请求 Cookie 中的会话令牌
+func (m *Manager) Token(c *gin.Context) string {
+	token, err := c.Cookie(m.cookieName)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// SetCookie 写入会话 Cookie，有效期与会话的最长有效期一致
+func (m *Manager) SetCookie(c *gin.Context, token string, sess *Session) {
+	m.writeCookie(c, token, int(time.Until(sess.CreatedAt.Add(m.lifetime)).Seconds()))
+}
+
+// ClearCookie 删除会话 Cookie
+func (m *Manager) ClearCookie(c *gin.Context) {
+	m.writeCookie(c, "", -1)
+}
+
+func (m *Manager) writeCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     m.cookieName,
+		Value:    value,
+		Path:     m.path,
+		Domain:   m.domain,
+		MaxAge:   maxAge,
+		Secure:   m.secure,
+		HttpOnly: true,
+		SameSite: m.sameSite,
+	})
+}
+
+// expiresAt 会话在空闲超时和最长有效期中较早的时间失效
+func (m *Manager) expiresAt(createdAt, lastSeenAt time.Time) time.Time {
+	idle := lastSeenAt.Add(m.idleTimeout)
+	if absolute := createdAt.Add(m.lifetime); absolute.Before(idle) {
+		return absolute
+	}
+	return idle
+}
@@ -0,0 +1,73 @@
+// Package session 提供服务端会话，作为 JWT 之外的另一种登录方式
+//
+// 会话 ID 为随机令牌，只保存在 HttpOnly Cookie 中；存储中只保存其 SHA-256 哈希，
+// 泄露存储内容不会泄露可用的会话。每个会话带有独立的 CSRF 令牌，
+// 通过 Cookie 认证的非安全方法请求必须在请求头中携带该令牌。
+package session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"evaframe/pkg/config"
+	"evaframe/pkg/registry"
+
+	"github.com/google/wire"
+	"gorm.io/gorm"
+)
+
+func init() {
+	registry.RegisterModel(&Session{})
+}
+
+var ProviderSet = wire.NewSet(NewStore, NewManager)
+
+// ErrNotFound 会话不存在或已过期
+var ErrNotFound = errors.New("session not found")
+
+// Session 服务端会话，登录时记录用户的角色和权限；修改角色时用户的所有会话被删除，重新登录后按新角色生效
+type Session struct {
+	ID          string    `gorm:"primarykey;size:64" json:"id"` // 会话令牌的 SHA-256 哈希
+	UserID      uint      `gorm:"index;not null" json:"user_id"`
+	Email       string    `gorm:"size:255" json:"email"`
+	Roles       []string  `gorm:"serializer:json" json:"roles"`
+	Permissions []string  `gorm:"serializer:json" json:"permissions"`
+	CSRFToken   string    `gorm:"size:64;not null" json:"csrf_token"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	ExpiresAt   time.Time `gorm:"index;not null" json:"expires_at"` // 空闲超时与最长有效期中较早的一个
+}
+
+// Store 会话存储接口
+type Store interface {
+	// Get 返回未过期的会话，不存在或已过期时返回 ErrNotFound
+	Get(id string) (*Session, error)
+	// Save 创建或更新会话
+	Save(sess *Session) error
+	// Touch 续期时只更新已存在的会话，会话已被删除时返回 ErrNotFound 而不是重新创建
+	Touch(id string, lastSeenAt, expiresAt time.Time) error
+	Delete(id string) error
+	// DeleteByUser 删除用户的所有会话，exceptID 不为空时保留该会话
+	DeleteByUser(userID uint, exceptID string) error
+	// DeleteExpired 清理已过期的会话
+	DeleteExpired(now time.Time) error
+}
+
+// NewStore 根据配置创建会话存储 Provider
+func NewStore(cfg *config.Config, db *gorm.DB) (Store, error) {
+	switch cfg.Session.Store {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "gorm":
+		return NewGormStore(db), nil
+	case "file":
+		dir := cfg.Session.FileDir
+		if dir == "" {
+			dir = "storage/sessions"
+		}
+		return NewFileStore(dir)
+	default:
+		return nil, fmt.Errorf("不支持的会话存储: %s", cfg.Session.Store)
+	}
+}
@@ -0,0 +1,230 @@
+package session
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"evaframe/pkg/config"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestManager(t *testing.T, store Store) *Manager {
+	t.Helper()
+	var cfg config.Config
+	cfg.Auth.Mode = "session"
+	m, err := NewManager(&cfg, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func newTestStores(t *testing.T) map[string]Store {
+	fileStore, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "sessions.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Session{}); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   fileStore,
+		"gorm":   NewGormStore(db),
+	}
+}
+
+func TestCreateAndLoad(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			m := newTestManager(t, store)
+
+			sess, token, err := m.Create(Identity{UserID: 1, Email: "a@example.com", Permissions: []string{"users:list"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sess.ID == token {
+				t.Fatal("store must not keep the raw session token")
+			}
+
+			loaded, err := m.Load(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.UserID != 1 || loaded.CSRFToken != sess.CSRFToken || len(loaded.Permissions) != 1 {
+				t.Fatalf("loaded = %+v, want the created session", loaded)
+			}
+
+			if _, err := m.Load(sess.ID); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("load by stored id err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+// TestCreateAlwaysIssuesNewID 登录时总是生成新的会话 ID，防止会话固定攻击
+func TestCreateAlwaysIssuesNewID(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore())
+
+	first, _, err := m.Create(Identity{UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := m.Create(Identity{UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID || first.CSRFToken == second.CSRFToken {
+		t.Fatal("each login must get a fresh session id and csrf token")
+	}
+}
+
+func TestDestroyUserKeepsExcept(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			m := newTestManager(t, store)
+
+			keep, keepToken, _ := m.Create(Identity{UserID: 1})
+			_, otherToken, _ := m.Create(Identity{UserID: 1})
+			_, strangerToken, _ := m.Create(Identity{UserID: 2})
+
+			if err := m.DestroyUser(1, keep.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := m.Load(keepToken); err != nil {
+				t.Fatalf("kept session: %v", err)
+			}
+			if _, err := m.Load(otherToken); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("other session err = %v, want ErrNotFound", err)
+			}
+			if _, err := m.Load(strangerToken); err != nil {
+				t.Fatalf("other user's session: %v", err)
+			}
+		})
+	}
+}
+
+// TestTouchDoesNotRecreateDestroyedSession 请求加载会话后、续期前会话被登出或修改密码删除，
+// 续期不能把会话写回去
+func TestTouchDoesNotRecreateDestroyedSession(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			m := newTestManager(t, store)
+
+			sess, token, err := m.Create(Identity{UserID: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := store.Get(sess.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := m.Destroy(sess.ID); err != nil {
+				t.Fatal(err)
+			}
+			now := time.Now()
+			if err := store.Touch(loaded.ID, now, m.expiresAt(loaded.CreatedAt, now)); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("touch err = %v, want ErrNotFound", err)
+			}
+
+			if _, err := m.Load(token); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("load err = %v, want ErrNotFound", err)
+			}
+			if _, err := store.Get(sess.ID); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("destroyed session was recreated: err = %v", err)
+			}
+		})
+	}
+}
+
+// TestLoadExtendsIdleTimeout 超过续期间隔的请求延长会话的空闲超时
+func TestLoadExtendsIdleTimeout(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			m := newTestManager(t, store)
+
+			sess, token, err := m.Create(Identity{UserID: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sess.LastSeenAt = sess.LastSeenAt.Add(-10 * time.Minute)
+			sess.ExpiresAt = sess.ExpiresAt.Add(-10 * time.Minute)
+			if err := store.Save(sess); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := m.Load(token); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := store.Get(sess.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !stored.ExpiresAt.After(sess.ExpiresAt) || stored.CSRFToken != sess.CSRFToken {
+				t.Fatalf("stored = %+v, want the expiry extended and other fields kept", stored)
+			}
+		})
+	}
+}
+
+func TestExpiredSessionIsRejected(t *testing.T) {
+	for name, store := range newTestStores(t) {
+		t.Run(name, func(t *testing.T) {
+			m := newTestManager(t, store)
+
+			sess, token, _ := m.Create(Identity{UserID: 1})
+			sess.ExpiresAt = time.Now().Add(-time.Second)
+			if err := store.Save(sess); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := m.Load(token); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestExpiresAtIsCappedByLifetime(t *testing.T) {
+	m := newTestManager(t, NewMemoryStore())
+
+	created := time.Now().Add(-m.lifetime + time.Minute)
+	if got, want := m.expiresAt(created, time.Now()), created.Add(m.lifetime); !got.Equal(want) {
+		t.Fatalf("expiresAt = %v, want %v", got, want)
+	}
+}
+
+func TestSameSiteNoneRequiresSecure(t *testing.T) {
+	var cfg config.Config
+	cfg.Session.SameSite = "none"
+	if _, err := NewManager(&cfg, NewMemoryStore()); err == nil {
+		t.Fatal("want error for SameSite=None without Secure")
+	}
+
+	secure := true
+	cfg.Session.Secure = &secure
+	if _, err := NewManager(&cfg, NewMemoryStore()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStoreRejectsPathTraversal(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get("../../etc/passwd"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
@@ -1,7 +1,9 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -30,3 +32,74 @@ func (v *Validator) Validate(data any) error {
 	}
 	return nil
 }
+
+// NewValidatorWithTagName 创建使用指定结构体标签（如 json、mapstructure）作为字段名的校验器
+func NewValidatorWithTagName(tag string) *Validator {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	return &Validator{validate: validate}
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field string // 以 . 分隔的完整路径，如 server.port、oidc.providers[0].name
+	Tag   string // 未通过的校验规则，如 required、oneof
+	Param string // 校验规则的参数
+	Value any    // 字段的实际值
+}
+
+func (e *FieldError) Error() string {
+	switch e.Tag {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return fmt.Sprintf("%s is required", e.Field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s], got %q", e.Field, e.Param, fmt.Sprint(e.Value))
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s, got %v", e.Field, e.Param, e.Value)
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s, got %v", e.Field, e.Param, e.Value)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s, got %v", e.Field, e.Param, e.Value)
+	case "ip|cidr":
+		return fmt.Sprintf("%s must be an IP address or CIDR, got %q", e.Field, fmt.Sprint(e.Value))
+	case "excluded_if":
+		return fmt.Sprintf("%s must not be set when %s", e.Field, e.Param)
+	case "url", "http_url":
+		return fmt.Sprintf("%s must be a valid URL, got %q", e.Field, fmt.Sprint(e.Value))
+	default:
+		return fmt.Sprintf("%s failed validation: %s", e.Field, e.Tag)
+	}
+}
+
+// Fields 校验结构体并返回所有字段错误，字段路径不包含最外层结构体的名称
+func (v *Validator) Fields(data any) ([]*FieldError, error) {
+	err := v.validate.Struct(data)
+	if err == nil {
+		return nil, nil
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, err
+	}
+
+	fields := make([]*FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		_, path, _ := strings.Cut(fe.Namespace(), ".")
+		fields = append(fields, &FieldError{
+			Field: path,
+			Tag:   fe.Tag(),
+			Param: fe.Param(),
+			Value: fe.Value(),
+		})
+	}
+	return fields, nil
+}